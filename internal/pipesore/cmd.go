@@ -2,59 +2,282 @@ package pipesore
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 
+	"github.com/dyson/pipesore/pkg/ansi"
 	"github.com/dyson/pipesore/pkg/pipeline"
 )
 
 func Run(version, commit, date string) (int, error) {
 	seeHelp := fmt.Sprintf("See '%s --help'", filepath.Base(os.Args[0]))
 
-	if len(os.Args) != 2 {
-		return 1, fmt.Errorf("error: define a single pipeline or option.\n%s.", seeHelp)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			return runFormat(os.Args[2:], os.Stdin, os.Stdout)
+		case "lsp":
+			if err := runLSP(os.Stdin, os.Stdout, version); err != nil {
+				return 1, err
+			}
+			return 0, nil
+		}
 	}
 
-	input := os.Args[1]
+	flags := flag.NewFlagSet("pipesore", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	help := flags.Bool("h", false, "")
+	flags.BoolVar(help, "help", false, "")
+	showVersion := flags.Bool("v", false, "")
+	flags.BoolVar(showVersion, "version", false, "")
+	output := flags.String("o", "", "")
+	inPlace := flags.Bool("i", false, "")
+	flags.BoolVar(inPlace, "in-place", false, "")
+	backup := flags.String("backup", "", "")
+	seed := flags.Int64("seed", 0, "")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		return 1, fmt.Errorf("error: %w.\n%s.", err, seeHelp)
+	}
 
-	switch input {
-	case "-h", "--help":
+	switch {
+	case *help:
 		printHelp()
 		return 0, nil
-	case "-v", "--version":
+	case *showVersion:
 		fmt.Printf("pipesore version %s, commit %s, date %s\n", version, commit, date)
 		return 0, nil
-	case "":
+	case flags.NArg() < 1:
+		return 1, fmt.Errorf("error: define a single pipeline or option.\n%s.", seeHelp)
+	case flags.Arg(0) == "":
 		return 1, fmt.Errorf("error: no pipeline defined.\n%s.", seeHelp)
+	case *output != "" && *inPlace:
+		return 1, fmt.Errorf("error: -o and -i can't be used together.\n%s.", seeHelp)
+	case *backup != "" && !*inPlace:
+		return 1, fmt.Errorf("error: --backup can only be used with -i.\n%s.", seeHelp)
+	case *inPlace && flags.NArg() < 2:
+		return 1, fmt.Errorf("error: -i needs at least one file.\n%s.", seeHelp)
+	case *inPlace && slices.Contains(flags.Args()[1:], "-"):
+		return 1, fmt.Errorf("error: -i can't write to stdin.\n%s.", seeHelp)
+	}
+
+	input := flags.Arg(0)
+	names := flags.Args()[1:]
+
+	opts := executeOptions{outputs: map[string]bool{}}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.seed = pipeline.Seed{Value: *seed, Set: true}
+		}
+	})
+
+	run := func(names []string, out io.Writer) error {
+		in, closeFiles, err := openFiles(names)
+		if err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		defer closeFiles()
+
+		return execute(input, in, out, opts)
+	}
+
+	var err error
+
+	switch {
+	case *inPlace:
+		opts.noColor = true
+
+		for _, name := range names {
+			err = runInPlace(name, *backup, func(out io.Writer) error {
+				return run([]string{name}, out)
+			})
+			if err != nil {
+				break
+			}
+		}
+	case *output != "":
+		opts.noColor = true
+
+		err = writeFile(*output, func(out io.Writer) error {
+			return run(names, out)
+		})
+	default:
+		opts.noColor = !ansi.Enabled(os.Stdout)
+
+		err = run(names, os.Stdout)
+	}
+
+	if err != nil {
+		errs := unwrapErrors(err)
+
+		formattedErrs := []error{}
+		for i, err := range errs {
+			help := ""
+			if i == len(errs)-1 {
+				help = seeHelp
+			}
+
+			formattedErrs = append(formattedErrs, formatError(err, input, help, ansi.Enabled(os.Stderr)))
+		}
+
+		return 1, errors.Join(formattedErrs...)
+	}
+
+	return 0, nil
+}
+
+// writeFile calls 'write' with a temporary file in the same directory as the
+// file 'name' which then replaces it, so that 'name' is left unchanged if
+// 'write' fails. The permissions of an existing file are kept.
+func writeFile(name string, write func(io.Writer) error) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
 	}
 
-	err := execute(input, os.Stdin, os.Stdout)
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
 	if err != nil {
-		var syntaxError *syntaxError
-		if errors.As(err, &syntaxError) {
-			return 1, newFormattedError(err, input, syntaxError.position, seeHelp)
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	// the temporary file no longer exists once renamed
+	defer os.Remove(f.Name())
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), name); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	return nil
+}
+
+// runInPlace replaces the file 'name' with the output 'run' writes. If
+// 'backup' isn't empty the file is first copied to 'name' with the suffix
+// 'backup', once the output has been written.
+func runInPlace(name, backup string, run func(io.Writer) error) error {
+	return writeFile(name, func(w io.Writer) error {
+		if err := run(w); err != nil {
+			return err
+		}
+
+		if backup == "" {
+			return nil
 		}
 
-		var filterNameError *filterNameError
-		if errors.As(err, &filterNameError) {
-			if filterNameError.suggestion != "" {
-				definition := pipeline.Filters[filterNameError.suggestion].Definition
-				seeHelp = fmt.Sprintf("Did you mean '%s'?\n%s", definition, seeHelp)
+		return writeFile(name+backup, func(w io.Writer) error {
+			f, err := os.Open(name)
+			if err != nil {
+				return fmt.Errorf("error writing file: %w", err)
 			}
+			defer f.Close()
 
-			return 1, newFormattedError(err, input, filterNameError.position, seeHelp)
+			if _, err := io.Copy(w, f); err != nil {
+				return fmt.Errorf("error writing file: %w", err)
+			}
+
+			return nil
+		})
+	})
+}
+
+// openFiles returns a reader of the named files, or stdin if there are none,
+// and a function to close them. The name "-" is stdin.
+func openFiles(names []string) (io.Reader, func(), error) {
+	if len(names) == 0 {
+		return os.Stdin, func() {}, nil
+	}
+
+	files := []pipeline.File{}
+	closers := []io.Closer{}
+
+	closeFiles := func() {
+		for _, c := range closers {
+			c.Close()
 		}
+	}
 
-		var filterArgumentError *filterArgumentError
-		if errors.As(err, &filterArgumentError) {
-			help := fmt.Sprintf("%s. %s", pipeline.Filters[filterArgumentError.name].Definition, seeHelp)
+	for _, name := range names {
+		if name == "-" {
+			files = append(files, pipeline.File{Name: name, Reader: os.Stdin})
+			continue
+		}
 
-			return 1, newFormattedError(err, input, filterArgumentError.position, help)
+		f, err := os.Open(name)
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
 		}
 
-		return 1, fmt.Errorf("%w.\n%s.", err, seeHelp)
+		files = append(files, pipeline.File{Name: name, Reader: f})
+		closers = append(closers, f)
 	}
 
-	return 0, nil
+	return pipeline.NewFiles(files...), closeFiles, nil
+}
+
+// formatError underlines the position of the error in the input and adds any
+// filter suggestion or definition to the help. The position is only coloured
+// if 'color' is true.
+func formatError(err error, input, help string, color bool) error {
+	var syntaxError *syntaxError
+	if errors.As(err, &syntaxError) {
+		return newFormattedError(err, input, syntaxError.position, help, color)
+	}
+
+	var filterNameError *filterNameError
+	if errors.As(err, &filterNameError) {
+		if filterNameError.suggestion != "" {
+			definition := pipeline.Filters[filterNameError.suggestion].Definition
+			help = joinHelp(fmt.Sprintf("Did you mean '%s'?", definition), help, "\n")
+		}
+
+		return newFormattedError(err, input, filterNameError.position, help, color)
+	}
+
+	var filterArgumentError *filterArgumentError
+	if errors.As(err, &filterArgumentError) {
+		help = joinHelp(pipeline.Filters[filterArgumentError.name].Definition, help, ". ")
+
+		return newFormattedError(err, input, filterArgumentError.position, help, color)
+	}
+
+	var filterStreamError *filterStreamError
+	if errors.As(err, &filterStreamError) {
+		help = joinHelp(pipeline.Filters[filterStreamError.name].Definition, help, ". ")
+
+		return newFormattedError(err, input, filterStreamError.position, help, color)
+	}
+
+	if help == "" {
+		return fmt.Errorf("%w.", err)
+	}
+
+	return fmt.Errorf("%w.\n%s.", err, help)
+}
+
+func joinHelp(help, more, separator string) string {
+	if more == "" {
+		return help
+	}
+
+	return help + separator + more
 }
@@ -0,0 +1,183 @@
+package pipesore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunInPlace(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "in.txt")
+	if err := os.WriteFile(name, []byte("apple\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	upper := func(w io.Writer) error {
+		in, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		return execute(`Upper()`, in, w, executeOptions{})
+	}
+
+	if err := runInPlace(name, ".bak", upper); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{name: "APPLE\n", name + ".bak": "apple\n"} {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want != string(got) {
+			log.Fatalf("(file %s) wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Fatalf("wanted mode: %v, got: %v", os.FileMode(0o640), info.Mode().Perm())
+	}
+
+	// the file is unchanged, and no temporary file is left, if the pipeline
+	// fails
+	failing := func(w io.Writer) error {
+		io.WriteString(w, "partial\n")
+		return errors.New("failed")
+	}
+
+	if err := runInPlace(name, "", failing); err == nil {
+		t.Fatal("wanted error, got: nil")
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "APPLE\n" {
+		log.Fatalf("wanted: %q, got: %q", "APPLE\n", string(got))
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("wanted 2 files, got: %v", entries)
+	}
+}
+
+// TestRunInPlaceSplitTo runs SplitTo for each file as -i does, checking the
+// files written for earlier input files are appended to rather than
+// truncated.
+func TestRunInPlaceSplitTo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	names := []string{filepath.Join(dir, "f1.txt"), filepath.Join(dir, "f2.txt")}
+	for i, name := range names {
+		if err := os.WriteFile(name, []byte(fmt.Sprintf("a %d\nb %d\n", i+1, i+1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := executeOptions{outputs: map[string]bool{}}
+
+	run := func(pipeline, name string) error {
+		return runInPlace(name, "", func(w io.Writer) error {
+			in, closeFiles, err := openFiles([]string{name})
+			if err != nil {
+				return err
+			}
+			defer closeFiles()
+
+			return execute(pipeline, in, w, opts)
+		})
+	}
+
+	splitTo := fmt.Sprintf(`SplitTo(%q, " ") | Match("zzz")`, filepath.Join(dir, "out", "{1}.log"))
+
+	for _, name := range names {
+		if err := run(splitTo, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{"a.log": "a 1\na 2\n", "b.log": "b 1\nb 2\n"} {
+		got, err := os.ReadFile(filepath.Join(dir, "out", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want != string(got) {
+			log.Fatalf("(file %s) wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+
+	// a line naming the input file is an error and the input is unchanged
+	if err := os.WriteFile(names[0], []byte("f1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := fmt.Sprintf("error filtering pipeline: file name must not also be an input, got: %q", names[0])
+
+	err := run(fmt.Sprintf(`SplitTo(%q, " ")`, filepath.Join(dir, "{1}.txt")), names[0])
+	if err == nil || err.Error() != want {
+		t.Fatalf("wanted error: %q, got: %v", want, err)
+	}
+
+	got, err := os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "f1\n" {
+		log.Fatalf("wanted: %q, got: %q", "f1\n", string(got))
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	t.Parallel()
+
+	err := check(`Frist(1)`)
+
+	tests := []struct {
+		color bool
+		want  string
+	}{
+		{false, "error running pipeline: unknown filter 'Frist()':\n\tFrist(1)\nDid you mean 'First(n int)'?."},
+		{true, "error running pipeline: unknown filter 'Frist()':\n\t\x1b[31m\x1b[4:3mFrist\x1b[0m(1)\nDid you mean 'First(n int)'?."},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := formatError(err, `Frist(1)`, "", tc.color).Error()
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package pipesore
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dyson/pipesore/pkg/ansi"
+)
 
 type syntaxError struct {
 	err error
@@ -56,11 +61,27 @@ func (fne *filterArgumentError) Error() string {
 	return fne.err.Error()
 }
 
-func newFormattedError(err error, input string, position position, help string) error {
-	red := "\x1b[31m"
-	undercurl := "\x1b[4:3m"
-	reset := "\x1b[0m"
+type filterStreamError struct {
+	err  error
+	name string
+	position
+}
+
+func newFilterStreamError(err error, position position, name string) *filterStreamError {
+	return &filterStreamError{
+		err:      err,
+		position: position,
+		name:     name,
+	}
+}
+
+func (fse *filterStreamError) Error() string {
+	return fse.err.Error()
+}
 
+// newFormattedError returns the error followed by the input with the position
+// of the error underlined, in red if 'color' is true.
+func newFormattedError(err error, input string, position position, help string, color bool) error {
 	var inputBefore, inputAfter string
 
 	start := position.start
@@ -85,15 +106,64 @@ func newFormattedError(err error, input string, position position, help string)
 		help = "\n" + help
 	}
 
+	if !color {
+		return fmt.Errorf("%w:\n\t%s%s%s%s.", err, inputBefore, inputError, inputAfter, help)
+	}
+
 	return fmt.Errorf(
 		"%w:\n\t%s%s%s%s%s%s%s.",
 		err,
 		inputBefore,
-		red,
-		undercurl,
+		ansi.Red,
+		ansi.Undercurl,
 		inputError,
-		reset,
+		ansi.Reset,
 		inputAfter,
 		help,
 	)
 }
+
+// unwrapErrors returns the individual errors of an errors.Join() error, or
+// the error itself if it isn't joined.
+func unwrapErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []error{}
+		for _, err := range joined.Unwrap() {
+			errs = append(errs, unwrapErrors(err)...)
+		}
+
+		return errs
+	}
+
+	return []error{err}
+}
+
+// errorPosition returns the position in the input of a syntax, filter name or
+// filter argument error.
+func errorPosition(err error) (position, bool) {
+	var syntaxError *syntaxError
+	if errors.As(err, &syntaxError) {
+		return syntaxError.position, true
+	}
+
+	var filterNameError *filterNameError
+	if errors.As(err, &filterNameError) {
+		return filterNameError.position, true
+	}
+
+	var filterArgumentError *filterArgumentError
+	if errors.As(err, &filterArgumentError) {
+		return filterArgumentError.position, true
+	}
+
+	var filterStreamError *filterStreamError
+	if errors.As(err, &filterStreamError) {
+		return filterStreamError.position, true
+	}
+
+	return position{}, false
+}
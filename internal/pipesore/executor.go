@@ -1,97 +1,367 @@
 package pipesore
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/dyson/pipesore/pkg/ansi"
 	"github.com/dyson/pipesore/pkg/levenshtein"
 	"github.com/dyson/pipesore/pkg/pipeline"
 )
 
-func execute(input string, in io.Reader, out io.Writer) error {
-	tree, err := newParser(newLexer(input)).parse()
-	if err != nil {
-		return fmt.Errorf("error parsing pipeline: %w", err)
+// executeOptions change how a pipeline is executed.
+type executeOptions struct {
+	// noColor disables the colours written by highlight filters.
+	noColor bool
+
+	// seed seeds the random filters so the output is the same each run, if
+	// set.
+	seed pipeline.Seed
+
+	// outputs are the names of the files created by filters in earlier runs,
+	// such as for each file with -i, which are appended to rather than
+	// truncated.
+	outputs map[string]bool
+}
+
+func execute(input string, in io.Reader, out io.Writer, opts executeOptions) error {
+	tree, parseErr := newParser(newLexer(input)).parse()
+
+	e := newExecutor(tree, in, out)
+	e.noColor = opts.noColor
+	e.seed = opts.seed
+	e.inputs = inputFiles(in)
+
+	outputs := opts.outputs
+	if outputs == nil {
+		outputs = map[string]bool{}
+	}
+
+	// files given as filter arguments are only opened (or created) once a
+	// filter reads (or writes) them, so nothing is changed if the pipeline
+	// isn't valid
+	e.open = func(name string) (io.ReadCloser, error) {
+		return os.Open(name)
+	}
+	e.create = func(name string) (io.WriteCloser, error) {
+		if outputs[name] {
+			return os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+		}
+
+		f, err := os.Create(name)
+		if err == nil {
+			outputs[name] = true
+		}
+
+		return f, err
+	}
+	defer e.close()
+
+	filters, compileErr := e.compile()
+
+	if err := pipelineErrors(parseErr, compileErr); err != nil {
+		return err
+	}
+
+	return e.execute(filters)
+}
+
+// inputFiles returns the files read by 'in', which is stdin or the reader
+// returned by openFiles().
+func inputFiles(in io.Reader) []os.FileInfo {
+	infos := []os.FileInfo{}
+
+	stat := func(f *os.File) {
+		if info, err := f.Stat(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+
+	switch in := in.(type) {
+	case *os.File:
+		stat(in)
+	case interface{ Names() []string }:
+		for _, name := range in.Names() {
+			if name == "-" {
+				stat(os.Stdin)
+				continue
+			}
+
+			if info, err := os.Stat(name); err == nil {
+				infos = append(infos, info)
+			}
+		}
 	}
 
-	return newExecutor(tree, in, out).execute()
+	return infos
+}
+
+// check returns all errors in the pipeline without executing it.
+func check(input string) error {
+	tree, parseErr := newParser(newLexer(input)).parse()
+
+	_, compileErr := newExecutor(tree, nil, nil).compile()
+
+	return pipelineErrors(parseErr, compileErr)
+}
+
+// pipelineErrors joins the parse and compile errors ordered by their position
+// in the input.
+func pipelineErrors(parseErr, compileErr error) error {
+	errs := []error{}
+	for _, err := range unwrapErrors(parseErr) {
+		errs = append(errs, fmt.Errorf("error parsing pipeline: %w", err))
+	}
+
+	errs = append(errs, unwrapErrors(compileErr)...)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		iPosition, _ := errorPosition(errs[i])
+		jPosition, _ := errorPosition(errs[j])
+
+		return iPosition.start < jPosition.start
+	})
+
+	return errors.Join(errs...)
 }
 
 type executor struct {
 	tree   *ast
 	reader io.Reader
 	writer io.Writer
+
+	// open and create open the files given as filter arguments. Files aren't
+	// opened if they're nil, such as when only checking the pipeline.
+	open    func(name string) (io.ReadCloser, error)
+	create  func(name string) (io.WriteCloser, error)
+	closers []io.Closer
+
+	// inputs are the files read by the pipeline, which can't also be written.
+	inputs []os.FileInfo
+
+	// noColor disables the colours of highlight filters and highlights counts
+	// the highlight filters compiled so each one starts on the next colour.
+	noColor    bool
+	highlights int
+
+	// seed seeds the random filters, each of which adds the number of random
+	// filters compiled before it so they don't repeat each other.
+	seed    pipeline.Seed
+	randoms int
 }
 
 func newExecutor(tree *ast, r io.Reader, w io.Writer) *executor {
 	return &executor{tree: tree, reader: r, writer: w}
 }
 
-func (e executor) execute() error {
-	p := pipeline.NewPipeline(e.reader)
+// close closes the files opened for filter arguments.
+func (e *executor) close() {
+	for _, c := range e.closers {
+		c.Close()
+	}
+}
+
+func (e *executor) execute(filters []any) error {
+	if err := pipeline.Compose(filters...)(e.reader, e.writer); err != nil {
+		return fmt.Errorf("error filtering pipeline: %w", err)
+	}
+
+	return nil
+}
+
+// compile returns the filter functions for the pipeline. All unknown filter
+// names, invalid filter arguments and filters given lines instead of records
+// (or records instead of lines) are returned (joined) rather than only the
+// first.
+func (e *executor) compile() ([]any, error) {
+	e.highlights = 0
+	e.randoms = 0
+
+	return e.compileFilters(e.tree.filters)
+}
+
+// compileFilters returns the filter functions for the pipeline of 'inFilters',
+// which may be the whole pipeline or a pipeline given as a filter argument.
+func (e *executor) compileFilters(inFilters []filter) ([]any, error) {
+	filters := []any{}
+	errs := []error{}
 
-	for _, inFilter := range e.tree.filters {
+	// the stream is unknown after an unknown filter
+	current, known := lines, true
+
+	for _, inFilter := range inFilters {
 		name := strings.ToLower(inFilter.name)
 
 		filter, ok := pipeline.Filters[name]
 		if !ok {
-			lowestScore := len(name)
-			suggestion := ""
-			for f := range pipeline.Filters {
-				distance := levenshtein.Distance(name, f)
-				if distance < lowestScore {
-					lowestScore = distance
-					suggestion = f
-				}
-			}
-
-			return newFilterNameError(
+			errs = append(errs, newFilterNameError(
 				fmt.Errorf("error running pipeline: unknown filter '%s()'", inFilter.name),
 				inFilter.position,
 				inFilter.name,
-				suggestion,
-			)
+				levenshtein.Closest(name, pipeline.Filters.GetOrderedNames()),
+			))
+
+			known = false
+			continue
 		}
 
 		filterType := filter.Value.Type()
 
-		args, err := e.convertArguments(inFilter, filterType)
-		if err != nil {
-			return newFilterArgumentError(
-				fmt.Errorf("error running pipeline: %w", err),
+		in, out := filterStreams(filterType)
+		if known && in != current {
+			errs = append(errs, newFilterStreamError(
+				fmt.Errorf("error running pipeline: '%s()' takes %s but is given %s, %s", inFilter.name, in, current, in.hint()),
 				inFilter.position,
 				name,
-			)
+			))
+		}
+
+		current, known = out, true
+
+		args, err := e.convertArguments(inFilter, filterType)
+		if err != nil {
+			// errors in a pipeline given as an argument are already positioned
+			if _, ok := errorPosition(err); !ok {
+				err = newFilterArgumentError(
+					fmt.Errorf("error running pipeline: %w", err),
+					inFilter.position,
+					name,
+				)
+			}
+
+			errs = append(errs, err)
+
+			continue
 		}
 
-		p.Filter(filter.Value.Call(args)[0].Interface().(func(io.Reader, io.Writer) error))
+		filters = append(filters, filter.Value.Call(args)[0].Interface())
 	}
 
-	if _, err := p.Output(e.writer); err != nil {
-		return fmt.Errorf("error filtering pipeline: %w", err)
+	if known && current != lines {
+		last := inFilters[len(inFilters)-1]
+
+		errs = append(errs, newFilterStreamError(
+			fmt.Errorf("error running pipeline: pipeline must return lines but '%s()' returns %s, %s", last.name, current, lines.hint()),
+			last.position,
+			strings.ToLower(last.name),
+		))
 	}
 
-	return nil
+	return filters, errors.Join(errs...)
+}
+
+// A stream is what a filter reads or writes: lines of text or records.
+type stream int
+
+const (
+	lines stream = iota
+	records
+)
+
+func (s stream) String() string {
+	if s == records {
+		return "records"
+	}
+
+	return "lines"
+}
+
+// hint describes how to convert to the stream.
+func (s stream) hint() string {
+	if s == records {
+		return "split lines into records with Fields() or CSV()"
+	}
+
+	return "join records into lines with JoinFields() or ToCSV()"
+}
+
+// filterStreams returns the streams read and written by the function returned
+// from the filterType.
+func filterStreams(filterType reflect.Type) (stream, stream) {
+	in, out := lines, lines
+
+	fn := filterType.Out(0)
+	if fn.In(0).Kind() == reflect.Chan {
+		in = records
+	}
+	if fn.In(1).Kind() == reflect.Chan {
+		out = records
+	}
+
+	return in, out
 }
 
-func (e executor) convertArguments(inFilter filter, filterType reflect.Type) ([]reflect.Value, error) {
-	if len(inFilter.arguments) != filterType.NumIn() {
+// injectArguments returns the leading arguments of the filterType that are
+// supplied by the executor rather than given in the pipeline.
+func (e *executor) injectArguments(filterType reflect.Type) []reflect.Value {
+	args := []reflect.Value{}
+
+	for i := 0; i < filterType.NumIn(); i++ {
+		switch filterType.In(i).String() {
+		case "pipeline.Colors":
+			args = append(args, reflect.ValueOf(pipeline.Colors{
+				Start:   e.highlights % len(ansi.Colors),
+				NoColor: e.noColor,
+			}))
+			e.highlights++
+		case "pipeline.Seed":
+			seed := e.seed
+			seed.Value += int64(e.randoms)
+			args = append(args, reflect.ValueOf(seed))
+			e.randoms++
+		case "pipeline.Outputs":
+			outputs := pipeline.Outputs{}
+			if e.create != nil {
+				outputs.Create = e.createOutput
+			}
+			args = append(args, reflect.ValueOf(outputs))
+		default:
+			return args
+		}
+	}
+
+	return args
+}
+
+func (e *executor) convertArguments(inFilter filter, filterType reflect.Type) ([]reflect.Value, error) {
+	args := e.injectArguments(filterType)
+	injected := len(args)
+	numIn := filterType.NumIn() - injected
+
+	// a variadic filter takes at least one of its last argument
+	variadic := filterType.IsVariadic()
+
+	if len(inFilter.arguments) != numIn && !(variadic && len(inFilter.arguments) > numIn) {
 		argument := "argument"
-		if filterType.NumIn() > 1 {
+		if numIn > 1 {
 			argument += "s"
 		}
 
-		return nil, fmt.Errorf("expected %d %s in call to '%s()', got %d", filterType.NumIn(), argument, inFilter.name, len(inFilter.arguments))
+		atLeast := ""
+		if variadic {
+			atLeast = "at least "
+		}
+
+		return nil, fmt.Errorf("expected %s%d %s in call to '%s()', got %d", atLeast, numIn, argument, inFilter.name, len(inFilter.arguments))
 	}
 
-	args := []reflect.Value{}
+	pipelineErrs := []error{}
 
 	for i := 0; i < len(inFilter.arguments); i++ {
 		inArg := inFilter.arguments[i]
-		filterArgType := filterType.In(i)
+
+		var filterArgType reflect.Type
+		if variadic && i >= numIn-1 {
+			filterArgType = filterType.In(filterType.NumIn() - 1).Elem()
+		} else {
+			filterArgType = filterType.In(injected + i)
+		}
 
 		switch filterArgType.String() {
 		case "string":
@@ -108,6 +378,16 @@ func (e executor) convertArguments(inFilter filter, filterType reflect.Type) ([]
 
 			args = append(args, reflect.ValueOf(inArg))
 
+		case "float64":
+			switch inArg := inArg.(type) {
+			case float64:
+				args = append(args, reflect.ValueOf(inArg))
+			case int:
+				args = append(args, reflect.ValueOf(float64(inArg)))
+			default:
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a float, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
 		case "*regexp.Regexp":
 			if reflect.TypeOf(inArg).String() != "string" {
 				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid regex.Regexp string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
@@ -119,8 +399,219 @@ func (e executor) convertArguments(inFilter filter, filterType reflect.Type) ([]
 			}
 
 			args = append(args, reflect.ValueOf(re))
+
+		case "*pipeline.JSONPath":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a JSON path string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			path, err := pipeline.ParseJSONPath(inArg.(string))
+			if err != nil {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid JSON path string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+			}
+
+			args = append(args, reflect.ValueOf(path))
+
+		case "[]*pipeline.JSONPath":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a comma separated JSON paths string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			paths, err := pipeline.ParseJSONPaths(inArg.(string))
+			if err != nil {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid comma separated JSON paths string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+			}
+
+			args = append(args, reflect.ValueOf(paths))
+
+		case "pipeline.InvalidPolicy":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be an invalid policy string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			policy, err := pipeline.ParseInvalidPolicy(inArg.(string))
+			if err != nil {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid invalid policy string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+			}
+
+			args = append(args, reflect.ValueOf(policy))
+
+		case "norm.Form":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a normalization form string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			form, err := pipeline.ParseNormForm(inArg.(string))
+			if err != nil {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid normalization form string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+			}
+
+			args = append(args, reflect.ValueOf(form))
+
+		case "*template.Template":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a template string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			tmpl, err := pipeline.ParseTemplate(inArg.(string))
+			if err != nil {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid template string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+			}
+
+			args = append(args, reflect.ValueOf(tmpl))
+
+		case "pipeline.File":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a file name string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			file := pipeline.File{Name: inArg.(string)}
+
+			// the file is only opened once the filter reads it, but a missing
+			// file is still reported at its position in the pipeline
+			if e.open != nil {
+				if _, err := os.Stat(file.Name); err != nil {
+					return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a valid file name string, got %v (%T), err %v", i+1, inFilter.name, inArg, inArg, err)
+				}
+
+				f := &lazyReader{name: file.Name, open: e.open}
+
+				e.closers = append(e.closers, f)
+				file.Reader = f
+			}
+
+			args = append(args, reflect.ValueOf(file))
+
+		case "pipeline.OutputFile":
+			if reflect.TypeOf(inArg).String() != "string" {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a file name string, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			file := pipeline.OutputFile{Name: inArg.(string)}
+
+			if e.create != nil {
+				if e.isInput(file.Name) {
+					return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a file name string that isn't also an input, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+				}
+
+				f := &lazyWriter{name: file.Name, create: e.create}
+
+				e.closers = append(e.closers, f)
+				file.Writer = f
+			}
+
+			args = append(args, reflect.ValueOf(file))
+
+		case "pipeline.Branch":
+			tree, ok := inArg.(*ast)
+			if !ok {
+				return nil, fmt.Errorf("expected argument %d in call to '%s()' to be a pipeline, got %v (%T)", i+1, inFilter.name, inArg, inArg)
+			}
+
+			// all errors in the pipelines are returned, not only the first
+			filters, err := e.compileFilters(tree.filters)
+			if err != nil {
+				pipelineErrs = append(pipelineErrs, err)
+				continue
+			}
+
+			args = append(args, reflect.ValueOf(pipeline.Branch{
+				Name:   formatPipeline(tree),
+				Filter: pipeline.Compose(filters...),
+			}))
 		}
 	}
 
+	if len(pipelineErrs) > 0 {
+		return nil, errors.Join(pipelineErrs...)
+	}
+
 	return args, nil
 }
+
+// createOutput creates the file 'name' for a filter that only knows the names
+// of the files it writes once it runs, so a file that's also an input is an
+// error then rather than when compiling.
+func (e *executor) createOutput(name string) (io.WriteCloser, error) {
+	if e.isInput(name) {
+		return nil, fmt.Errorf("file name must not also be an input, got: %q", name)
+	}
+
+	return e.create(name)
+}
+
+// isInput returns true if the file 'name' is also read by the pipeline.
+func (e *executor) isInput(name string) bool {
+	info, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+
+	for _, input := range e.inputs {
+		if os.SameFile(info, input) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A lazyReader opens the file 'name' on the first read, so files given as
+// filter arguments are only opened if and when the filter reads them.
+type lazyReader struct {
+	name string
+	open func(name string) (io.ReadCloser, error)
+	rc   io.ReadCloser
+	err  error
+}
+
+func (lr *lazyReader) Read(p []byte) (int, error) {
+	if lr.rc == nil && lr.err == nil {
+		lr.rc, lr.err = lr.open(lr.name)
+	}
+
+	if lr.err != nil {
+		return 0, lr.err
+	}
+
+	return lr.rc.Read(p)
+}
+
+// Close closes the file if it was opened.
+func (lr *lazyReader) Close() error {
+	if lr.rc == nil {
+		return nil
+	}
+
+	return lr.rc.Close()
+}
+
+// A lazyWriter creates the file 'name' on the first write, so files given as
+// filter arguments are only created (or truncated) once the filter runs.
+type lazyWriter struct {
+	name   string
+	create func(name string) (io.WriteCloser, error)
+	wc     io.WriteCloser
+	err    error
+}
+
+func (lw *lazyWriter) Write(p []byte) (int, error) {
+	if lw.wc == nil && lw.err == nil {
+		lw.wc, lw.err = lw.create(lw.name)
+	}
+
+	if lw.err != nil {
+		return 0, lw.err
+	}
+
+	return lw.wc.Write(p)
+}
+
+// Close closes the file if it was created.
+func (lw *lazyWriter) Close() error {
+	if lw.wc == nil {
+		return nil
+	}
+
+	return lw.wc.Close()
+}
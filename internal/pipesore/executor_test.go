@@ -2,9 +2,17 @@ package pipesore
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/dyson/pipesore/pkg/pipeline"
 )
 
 func TestExecute(t *testing.T) {
@@ -16,7 +24,168 @@ func TestExecute(t *testing.T) {
 	want := "4 bird\n"
 	got := &bytes.Buffer{}
 
-	err := execute(filters, strings.NewReader(input), got)
+	err := execute(filters, strings.NewReader(input), got, executeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want != got.String() {
+		log.Fatalf("wanted: %q, got: %q", want, got.String())
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	t.Parallel()
+
+	filters := `Frist(1) | Match("a" | Frequncy() | First("1")`
+
+	want := []string{
+		"error running pipeline: unknown filter 'Frist()'",
+		"error parsing pipeline: unexpected '|': expected ','",
+		"error running pipeline: unknown filter 'Frequncy()'",
+		"error running pipeline: expected argument 1 in call to 'First()' to be an int, got 1 (string)",
+	}
+
+	err := execute(filters, strings.NewReader(""), &bytes.Buffer{}, executeOptions{})
+
+	got := []string{}
+	for _, err := range unwrapErrors(err) {
+		got = append(got, err.Error())
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("wanted: %q, got: %q", want, got)
+	}
+}
+
+func TestExecuteRecords(t *testing.T) {
+	t.Parallel()
+
+	input := "apple,red\nbanana,yellow\ncherry,red\n"
+	filters := `Fields(",") | MatchField(2, "red") | JoinFields(" ")`
+
+	want := "apple red\ncherry red\n"
+	got := &bytes.Buffer{}
+
+	err := execute(filters, strings.NewReader(input), got, executeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want != got.String() {
+		log.Fatalf("wanted: %q, got: %q", want, got.String())
+	}
+
+	for _, filters := range []string{`MatchField(1, "a") | JoinFields(",")`, `Fields(",") | Match("a")`, `Fields(",")`} {
+		var filterStreamError *filterStreamError
+		if err := execute(filters, strings.NewReader(input), got, executeOptions{}); !errors.As(err, &filterStreamError) {
+			t.Fatalf("wanted filter stream error for %q, got: %v", filters, err)
+		}
+	}
+}
+
+func TestExecuteFiles(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "other.txt")
+	if err := os.WriteFile(name, []byte("banana\ncherry\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "apple\nbanana\n"
+	filters := fmt.Sprintf(`Intersect(%q)`, name)
+
+	want := "banana\n"
+	got := &bytes.Buffer{}
+
+	err := execute(filters, strings.NewReader(input), got, executeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want != got.String() {
+		log.Fatalf("wanted: %q, got: %q", want, got.String())
+	}
+
+	var filterArgumentError *filterArgumentError
+	if err := execute(`Except("does-not-exist.txt")`, strings.NewReader(input), got, executeOptions{}); !errors.As(err, &filterArgumentError) {
+		t.Fatalf("wanted filter argument error, got: %v", err)
+	}
+
+	if err := check(`Except("does-not-exist.txt")`); err != nil {
+		t.Fatalf("wanted no error checking pipeline, got: %v", err)
+	}
+}
+
+func TestExecuteTee(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tee := filepath.Join(dir, "tee.txt")
+	filters := fmt.Sprintf(`Tee(%q)`, tee)
+
+	// the file isn't created if the pipeline isn't valid
+	if err := execute(filters+` | Frist(1)`, strings.NewReader("a\n"), &bytes.Buffer{}, executeOptions{}); err == nil {
+		t.Fatal("wanted error executing invalid pipeline")
+	}
+
+	if _, err := os.Stat(tee); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("wanted file not created, got: %v", err)
+	}
+
+	// the file is created even without input and is then appended to by
+	// later runs with the same options
+	opts := executeOptions{outputs: map[string]bool{}}
+
+	for _, input := range []string{"", "a\n", "b\n"} {
+		if err := execute(filters, strings.NewReader(input), io.Discard, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(tee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a\nb\n"; want != string(got) {
+		log.Fatalf("wanted: %q, got: %q", want, string(got))
+	}
+
+	// the file can't also be an input
+	in, closeFiles, err := openFiles([]string{tee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeFiles()
+
+	var filterArgumentError *filterArgumentError
+	if err := execute(filters, in, io.Discard, executeOptions{}); !errors.As(err, &filterArgumentError) {
+		t.Fatalf("wanted filter argument error, got: %v", err)
+	}
+
+	got, err = os.ReadFile(tee)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a\nb\n"; want != string(got) {
+		log.Fatalf("wanted input unchanged: %q, got: %q", want, string(got))
+	}
+}
+
+func TestExecutePipelineArguments(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "tee.txt")
+
+	input := "cat\ndog\ncat\n"
+	filters := fmt.Sprintf(`Tee(%q) | Fork(CountLines(), Frequency() | First(1))`, name)
+
+	want := "3\n2 cat\n"
+	got := &bytes.Buffer{}
+
+	err := execute(filters, strings.NewReader(input), got, executeOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -24,4 +193,140 @@ func TestExecute(t *testing.T) {
 	if want != got.String() {
 		log.Fatalf("wanted: %q, got: %q", want, got.String())
 	}
+
+	tee, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if input != string(tee) {
+		log.Fatalf("wanted: %q, got: %q", input, string(tee))
+	}
+
+	filters = `Fork(Frist(1), First("1") | Fields(",")) | Fork() | Fork("a")`
+
+	wantErrs := []string{
+		"error running pipeline: unknown filter 'Frist()'",
+		"error running pipeline: expected argument 1 in call to 'First()' to be an int, got 1 (string)",
+		"error running pipeline: pipeline must return lines but 'Fields()' returns records, join records into lines with JoinFields() or ToCSV()",
+		"error running pipeline: expected at least 1 argument in call to 'Fork()', got 0",
+		"error running pipeline: expected argument 1 in call to 'Fork()' to be a pipeline, got a (string)",
+	}
+
+	gotErrs := []string{}
+	for _, err := range unwrapErrors(check(filters)) {
+		gotErrs = append(gotErrs, err.Error())
+	}
+
+	if !reflect.DeepEqual(wantErrs, gotErrs) {
+		t.Fatalf("wanted: %q, got: %q", wantErrs, gotErrs)
+	}
+}
+
+func TestExecuteHighlight(t *testing.T) {
+	t.Parallel()
+
+	red, green, reset := "\x1b[31m", "\x1b[32m", "\x1b[0m"
+
+	tests := []struct {
+		opts executeOptions
+		want string
+	}{
+		{executeOptions{}, red + "a" + reset + green + "b" + reset + "c\n"},
+		{executeOptions{noColor: true}, "abc\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := execute(`Highlight("a") | Highlight("b")`, strings.NewReader("abc\n"), got, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestExecuteSeed(t *testing.T) {
+	t.Parallel()
+
+	input := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	opts := executeOptions{seed: pipeline.Seed{Value: 1, Set: true}}
+
+	tests := []struct {
+		filters string
+		want    string
+	}{
+		{`Shuffle()`, "2\n8\n5\n1\n10\n3\n4\n6\n9\n7\n"},
+		{`Sample(3)`, "5\n7\n8\n"},
+		// the second random filter is seeded with the next seed
+		{`Sample(10) | Shuffle()`, "6\n9\n7\n10\n5\n4\n8\n1\n3\n2\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := execute(tc.filters, strings.NewReader(input), got, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestLazyReader(t *testing.T) {
+	t.Parallel()
+
+	opened := 0
+	open := func(name string) (io.ReadCloser, error) {
+		opened++
+		if name == "missing.txt" {
+			return nil, os.ErrNotExist
+		}
+
+		return io.NopCloser(strings.NewReader("a\n")), nil
+	}
+
+	lr := &lazyReader{name: "in.txt", open: open}
+	if err := lr.Close(); err != nil || opened != 0 {
+		t.Fatalf("wanted file not opened before reading, opened: %d, err: %v", opened, err)
+	}
+
+	got, err := io.ReadAll(lr)
+	if err != nil || string(got) != "a\n" || opened != 1 {
+		t.Fatalf("wanted: %q opened once, got: %q opened %d times, err: %v", "a\n", got, opened, err)
+	}
+
+	missing := &lazyReader{name: "missing.txt", open: open}
+	for i := 0; i < 2; i++ {
+		if _, err := missing.Read(make([]byte, 1)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("wanted error: %v, got: %v", os.ErrNotExist, err)
+		}
+	}
+
+	if opened != 2 {
+		t.Fatalf("wanted a failed open not to be retried, opened: %d", opened)
+	}
 }
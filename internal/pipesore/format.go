@@ -0,0 +1,171 @@
+package pipesore
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dyson/pipesore/pkg/ansi"
+	"github.com/dyson/pipesore/pkg/pipeline"
+	"github.com/dyson/pipesore/pkg/textwidth"
+)
+
+// formatWidth is the width a formatted pipeline can be before each filter is
+// written on its own line.
+const formatWidth = 80
+
+// runFormat formats the pipelines in the files provided in args (or stdin if
+// there are none) and writes them to out. With -w each file is rewritten
+// instead, which is needed to format more than one file. With --check nothing
+// is formatted and instead the names of files that aren't already formatted
+// are written to out.
+func runFormat(args []string, in io.Reader, out io.Writer) (int, error) {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	check := flags.Bool("check", false, "")
+	write := flags.Bool("w", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1, fmt.Errorf("error: %w", err)
+	}
+
+	switch {
+	case *check && *write:
+		return 1, errors.New("error: --check and -w can't be used together")
+	case *write && flags.NArg() == 0:
+		return 1, errors.New("error: -w needs at least one file")
+	case !*check && !*write && flags.NArg() > 1:
+		return 1, errors.New("error: -w is needed to format more than one file")
+	}
+
+	type file struct {
+		name  string
+		input string
+	}
+
+	files := []file{}
+
+	if flags.NArg() == 0 {
+		input, err := io.ReadAll(in)
+		if err != nil {
+			return 1, fmt.Errorf("error reading stdin: %w", err)
+		}
+
+		files = append(files, file{"<standard input>", string(input)})
+	}
+
+	for _, name := range flags.Args() {
+		input, err := os.ReadFile(name)
+		if err != nil {
+			return 1, fmt.Errorf("error reading file: %w", err)
+		}
+
+		files = append(files, file{name, string(input)})
+	}
+
+	status := 0
+	errs := []error{}
+
+	for _, f := range files {
+		tree, err := newParser(newLexer(f.input)).parse()
+		if err != nil {
+			for _, err := range unwrapErrors(err) {
+				err = fmt.Errorf("error parsing pipeline in %s: %w", f.name, err)
+				errs = append(errs, formatError(err, f.input, "", ansi.Enabled(os.Stderr)))
+			}
+
+			status = 1
+			continue
+		}
+
+		formatted := format(tree)
+
+		if *check {
+			if formatted != f.input {
+				fmt.Fprintln(out, f.name)
+				status = 1
+			}
+
+			continue
+		}
+
+		if *write {
+			if formatted == f.input {
+				continue
+			}
+
+			err := writeFile(f.name, func(w io.Writer) error {
+				_, err := io.WriteString(w, formatted)
+				return err
+			})
+			if err != nil {
+				return 1, err
+			}
+
+			continue
+		}
+
+		fmt.Fprint(out, formatted)
+	}
+
+	return status, errors.Join(errs...)
+}
+
+// format returns the canonical form of the pipeline. Filter names are written
+// as they are defined, arguments are separated by ", " and filters by " | ".
+// Pipelines wider than formatWidth are written with one filter per line.
+func format(tree *ast) string {
+	filters := []string{}
+	for _, f := range tree.filters {
+		filters = append(filters, formatFilter(f))
+	}
+
+	formatted := strings.Join(filters, " | ")
+	if textwidth.String(formatted) > formatWidth {
+		formatted = strings.Join(filters, "\n  | ")
+	}
+
+	return formatted + "\n"
+}
+
+func formatFilter(f filter) string {
+	name := f.name
+	if filter, ok := pipeline.Filters[strings.ToLower(name)]; ok {
+		name = filter.Name()
+	}
+
+	args := []string{}
+	for _, arg := range f.arguments {
+		switch arg := arg.(type) {
+		case int:
+			args = append(args, strconv.Itoa(arg))
+		case float64:
+			f := strconv.FormatFloat(arg, 'f', -1, 64)
+			if !strings.Contains(f, ".") {
+				f += ".0"
+			}
+			args = append(args, f)
+		case string:
+			args = append(args, strconv.Quote(arg))
+		case *ast:
+			args = append(args, formatPipeline(arg))
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+// formatPipeline returns the canonical form of a pipeline given as a filter
+// argument, which is always written on one line.
+func formatPipeline(tree *ast) string {
+	filters := []string{}
+	for _, f := range tree.filters {
+		filters = append(filters, formatFilter(f))
+	}
+
+	return strings.Join(filters, " | ")
+}
@@ -0,0 +1,103 @@
+package pipesore
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`first(1)`, "First(1)\n"},
+		{`  FIRST( 1 )|!last(2)`, "First(1) | !Last(2)\n"},
+		{`Replace(" ","\n")|Unknown()`, "Replace(\" \", \"\\n\") | Unknown()\n"},
+		{"Match(\"🍎\")\n", "Match(\"🍎\")\n"},
+		{`Match("ｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘ") | First(1)`, "Match(\"ｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘｘ\")\n  | First(1)\n"},
+		{`samplerate(0.010)`, "SampleRate(0.01)\n"},
+		{`fork( countlines(),frequency()|first(5) )|tee("x")`, "Fork(CountLines(), Frequency() | First(5)) | Tee(\"x\")\n"},
+		{`SampleRate(1.00)`, "SampleRate(1.0)\n"},
+		{
+			`Replace(" ", "\n") | Frequency() | First(1) | Match("apple") | Replace("apple", "orange")`,
+			"Replace(\" \", \"\\n\")\n  | Frequency()\n  | First(1)\n  | Match(\"apple\")\n  | Replace(\"apple\", \"orange\")\n",
+		},
+	}
+
+	for k, tc := range tests {
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			tree, err := newParser(newLexer(tc.input)).parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := format(tree)
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got)
+			}
+
+			// formatting is idempotent
+			tree, err = newParser(newLexer(got)).parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != format(tree) {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, got, format(tree))
+			}
+		})
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	a := filepath.Join(dir, "a.pipe")
+	b := filepath.Join(dir, "b.pipe")
+
+	for name, input := range map[string]string{a: "first(1)", b: "Last(1)\n"} {
+		if err := os.WriteFile(name, []byte(input), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+
+	if _, err := runFormat([]string{a, b}, nil, out); err == nil || err.Error() != "error: -w is needed to format more than one file" {
+		t.Fatalf("wanted error for more than one file, got: %v", err)
+	}
+
+	status, err := runFormat([]string{"-w", a, b}, nil, out)
+	if status != 0 || err != nil {
+		t.Fatalf("wanted status 0, got: %d, %v", status, err)
+	}
+
+	if out.Len() != 0 {
+		log.Fatalf("wanted no output, got: %q", out.String())
+	}
+
+	for name, want := range map[string]string{a: "First(1)\n", b: "Last(1)\n"} {
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want != string(got) {
+			log.Fatalf("(file %s) wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+}
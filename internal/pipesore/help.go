@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/dyson/pipesore/pkg/pipeline"
+	"github.com/dyson/pipesore/pkg/textwidth"
 )
 
 func printHelp() {
@@ -14,15 +15,32 @@ func printHelp() {
 		wrap(sb, s)
 	}
 
+	// descriptions of commands and options wrap under themselves rather than
+	// the command or option
+	command := func(s string) {
+		wrapHanging(sb, s, len("  fmt  "))
+	}
+	option := func(s string) {
+		wrapHanging(sb, s, len("  --backup suffix  "))
+	}
+
 	w("pipesore - command-line text processor")
 	w("")
 	w("Usage:")
-	w("  pipesore '<filter>[ | <filter>]...'")
+	// the pipeline isn't broken across lines
+	w("  pipesore [-o file | -i [--backup suffix]] [--seed n]")
+	w("           '<filter>[ | <filter>]...' [file]...")
+	w("  pipesore fmt [--check | -w] [file]...")
+	w("  pipesore lsp")
 	w("  pipesore [option]")
 	w("")
+	w("  The pipeline reads the files in turn, or stdin if there are none or the file is \"-\", and writes to stdout.")
+	w("")
+	w("  Options must be given before the pipeline.")
+	w("")
 	w("Example:")
 	w("  $ echo \"cat cat cat dog bird bird bird bird\" | \\")
-	w("  pipesore 'Replace(\" \", \"\\n\") | Frequency() | First(1)'")
+	w("  pipesore 'Words() | Frequency() | First(1)'")
 	w("  4 bird")
 	w("")
 	w("Filters:")
@@ -32,6 +50,8 @@ func printHelp() {
 	w("")
 	w("  A filter prefixed with an \"!\" will return the opposite result of the non prefixed filter of the same name. For example `First(1)` would return only the first line of the input and `!First(1)` (read as not first) would skip the first line of the input and return all other lines.")
 	w("")
+	w("  Most filters take and return lines of text. Fields(), CSV() and ExtractFields() instead split each line into a record of fields so that filters that take records, such as MatchField(), can operate on fields. Records must be joined back into lines with JoinFields() or ToCSV() before a filter that takes lines or the end of the pipeline.")
+	w("")
 	w("  ---")
 	w("")
 	for _, name := range pipeline.Filters.GetOrderedNames() {
@@ -40,47 +60,50 @@ func printHelp() {
 		w("    " + filter.Description)
 		w("")
 	}
+	w("Colour:")
+	w("  Each highlight filter in a pipeline starts on the next colour, cycling through red, green, yellow, blue, magenta and cyan. Errors underline the part of the pipeline at fault in red. Colours are only written to stdout and stderr when they are terminals and the NO_COLOR environment variable isn't set. Output written with -o or -i is never coloured.")
+	w("")
+	w("Commands:")
+	command("  fmt  write the pipeline in the file (or stdin) in canonical form. With -w each file is rewritten instead, which is needed to format more than one file. With --check the names of files that aren't formatted are written instead and the exit status is 1.")
+	command("  lsp  run a Language Server Protocol server over stdin and stdout. Each document is treated as a single pipeline.")
+	w("")
 	w("Options:")
-	w("  -h, --help     show this help message")
-	w("  -v, --version  show pipesore version")
+	option("  -o file          write to file instead of stdout. The file is only replaced once the pipeline has finished without error.")
+	option("  -i, --in-place   run the pipeline on each file in turn, replacing the file with the output once the pipeline has finished without error.")
+	option("  --backup suffix  with -i, keep a copy of each file named with suffix appended.")
+	option("  --seed n         seed the random filters, such as Sample() and Shuffle(), so the output is the same each run.")
+	option("  -h, --help       show this help message")
+	option("  -v, --version    show pipesore version")
 
 	fmt.Printf(sb.String())
 }
 
+// wrap writes 's' to 'sb' wrapped to 80 columns with any leading indent
+// repeated on each line.
 func wrap(sb *strings.Builder, s string) {
 	width := 80
 
-	prefix := ""
-	for i := 0; i < len(s); i++ {
-		if s[i] != ' ' {
-			prefix = s[:i]
-			s = s[i:]
-			break
-		}
-	}
-
-	width = width - len(prefix)
+	trimmed := strings.TrimLeft(s, " ")
+	prefix := s[:len(s)-len(trimmed)]
 
-	lastSpace := 0
-	lastBreak := 0
+	for _, line := range textwidth.Wrap(trimmed, width-len(prefix)) {
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
 
-	for i := 0; i < len(s); {
-		if i == lastBreak+width {
-			sb.WriteString(prefix)
-			sb.WriteString(s[lastBreak:lastSpace])
-			sb.WriteString("\n")
-			lastBreak = lastSpace + 1
-			i = lastBreak
-		}
+// wrapHanging writes 's' to 'sb' wrapped to 80 columns with the lines after the
+// first indented by 'hang' columns, the width of the first 'hang' bytes of 's'.
+func wrapHanging(sb *strings.Builder, s string, hang int) {
+	width := 80
 
-		if s[i] == ' ' {
-			lastSpace = i
-		}
+	prefix := s[:hang]
+	for _, line := range textwidth.Wrap(s[hang:], width-hang) {
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+		sb.WriteString("\n")
 
-		i++
+		prefix = strings.Repeat(" ", hang)
 	}
-
-	sb.WriteString(prefix)
-	sb.WriteString(s[lastBreak:])
-	sb.WriteString("\n")
 }
@@ -33,9 +33,16 @@ func (l *lexer) getToken() token {
 			},
 		}
 	} else if isDigit(ch) {
+		tt = INT
+		tl = l.getString(isDigit)
+		if l.getChar(0) == '.' && isDigit(l.getChar(1)) {
+			tt = FLOAT
+			l.position++
+			tl += "." + l.getString(isDigit)
+		}
 		return token{
-			ttype:   INT,
-			literal: l.getString(isDigit),
+			ttype:   tt,
+			literal: tl,
 			position: position{
 				start: start,
 				end:   l.position,
@@ -143,7 +150,7 @@ func (l *lexer) getChar(offset int) byte {
 }
 
 func isWhitespace(ch byte) bool {
-	return ch == ' '
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
 func isFilter(ch byte) bool {
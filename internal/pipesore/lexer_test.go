@@ -9,7 +9,7 @@ import (
 func TestGetToken(t *testing.T) {
 	t.Parallel()
 
-	filters := `Replace(" ", "\n") | Freq() | First(1)`
+	filters := `Replace(" ", "\n") | Freq() | First(1) | SampleRate(0.25)`
 
 	tests := []token{
 		{ttype: FILTER, literal: "Replace", position: position{start: 0, end: 7}},
@@ -32,7 +32,14 @@ func TestGetToken(t *testing.T) {
 		{ttype: INT, literal: "1", position: position{start: 36, end: 37}},
 		{ttype: RPAREN, literal: ")", position: position{start: 37, end: 38}},
 
-		{ttype: EOF, literal: "", position: position{start: 38, end: 39}},
+		{ttype: PIPE, literal: "|", position: position{start: 39, end: 40}},
+
+		{ttype: FILTER, literal: "SampleRate", position: position{start: 41, end: 51}},
+		{ttype: LPAREN, literal: "(", position: position{start: 51, end: 52}},
+		{ttype: FLOAT, literal: "0.25", position: position{start: 52, end: 56}},
+		{ttype: RPAREN, literal: ")", position: position{start: 56, end: 57}},
+
+		{ttype: EOF, literal: "", position: position{start: 57, end: 58}},
 	}
 
 	l := newLexer(filters)
@@ -0,0 +1,486 @@
+package pipesore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dyson/pipesore/pkg/pipeline"
+)
+
+// runLSP runs a Language Server Protocol server over the provided reader and
+// writer (usually stdin and stdout) until the client sends the exit
+// notification. Each document is treated as a single pipeline.
+func runLSP(r io.Reader, w io.Writer, version string) error {
+	s := newLSPServer(r, w, version)
+	return s.serve()
+}
+
+type lspServer struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	version   string
+	documents map[string]string
+	shutdown  bool
+}
+
+func newLSPServer(r io.Reader, w io.Writer, version string) *lspServer {
+	return &lspServer{
+		reader:    bufio.NewReader(r),
+		writer:    w,
+		version:   version,
+		documents: map[string]string{},
+	}
+}
+
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type lspResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+}
+
+type lspErrorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   lspError         `json:"error"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type lspTextDocumentPosition struct {
+	TextDocument lspTextDocument `json:"textDocument"`
+	Position     lspPosition     `json:"position"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCodeAction struct {
+	Title       string           `json:"title"`
+	Kind        string           `json:"kind"`
+	Diagnostics []lspDiagnostic  `json:"diagnostics"`
+	Edit        lspWorkspaceEdit `json:"edit"`
+}
+
+type lspWorkspaceEdit struct {
+	Changes map[string][]lspTextEdit `json:"changes"`
+}
+
+type lspCompletionItem struct {
+	Label         string        `json:"label"`
+	Kind          int           `json:"kind"`
+	Detail        string        `json:"detail"`
+	Documentation lspMarkupText `json:"documentation"`
+}
+
+type lspMarkupText struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkupText `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+const (
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+
+	lspSeverityError = 1
+	lspFunctionKind  = 3
+	lspFullSync      = 1
+	lspQuickFix      = "quickfix"
+	lspMarkdown      = "markdown"
+	lspSource        = "pipesore"
+)
+
+func (s *lspServer) serve() error {
+	for {
+		msg, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading lsp message: %w", err)
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("error running lsp: exit before shutdown")
+			}
+
+			return nil
+		}
+
+		result, err := s.handle(msg)
+
+		var lspErr *lspError
+		if errors.As(err, &lspErr) {
+			// notifications have no id and can't be responded to
+			if msg.ID == nil {
+				continue
+			}
+
+			err = s.write(lspErrorResponse{JSONRPC: "2.0", ID: msg.ID, Error: *lspErr})
+		} else if err == nil && msg.ID != nil {
+			err = s.write(lspResponse{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (e *lspError) Error() string {
+	return e.Message
+}
+
+func (s *lspServer) handle(msg *lspMessage) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   lspFullSync,
+				"completionProvider": map[string]any{},
+				"hoverProvider":      true,
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{
+				"name":    "pipesore",
+				"version": s.version,
+			},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+
+		s.documents[params.TextDocument.URI] = params.TextDocument.Text
+
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument   lspTextDocument   `json:"textDocument"`
+			ContentChanges []lspTextDocument `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+
+		// with full sync the last change contains the whole document
+		if len(params.ContentChanges) > 0 {
+			s.documents[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+
+		delete(s.documents, params.TextDocument.URI)
+
+		return nil, nil
+
+	case "textDocument/completion":
+		return s.completion(), nil
+
+	case "textDocument/hover":
+		var params lspTextDocumentPosition
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+
+		return s.hover(params), nil
+
+	case "textDocument/codeAction":
+		var params struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+			Range        lspRange        `json:"range"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+
+		return s.codeActions(params.TextDocument.URI, params.Range), nil
+	}
+
+	if msg.ID == nil {
+		return nil, nil
+	}
+
+	return nil, &lspError{Code: lspMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+func (s *lspServer) diagnostics(uri string) []lspDiagnostic {
+	text := s.documents[uri]
+
+	diagnostics := []lspDiagnostic{}
+	for _, err := range unwrapErrors(check(text)) {
+		position, _ := errorPosition(err)
+
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range:    newLSPRange(text, position),
+			Severity: lspSeverityError,
+			Source:   lspSource,
+			Message:  err.Error(),
+		})
+	}
+
+	return diagnostics
+}
+
+func (s *lspServer) publishDiagnostics(uri string) error {
+	return s.write(lspNotification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params: map[string]any{
+			"uri":         uri,
+			"diagnostics": s.diagnostics(uri),
+		},
+	})
+}
+
+func (s *lspServer) completion() []lspCompletionItem {
+	items := []lspCompletionItem{}
+	for _, name := range pipeline.Filters.GetOrderedNames() {
+		filter := pipeline.Filters[name]
+
+		items = append(items, lspCompletionItem{
+			Label:         filter.Name(),
+			Kind:          lspFunctionKind,
+			Detail:        filter.Definition,
+			Documentation: lspMarkupText{Kind: lspMarkdown, Value: filter.Description},
+		})
+	}
+
+	return items
+}
+
+func (s *lspServer) hover(params lspTextDocumentPosition) any {
+	text := s.documents[params.TextDocument.URI]
+	offset := lspOffset(text, params.Position)
+
+	l := newLexer(text)
+	for t := l.getToken(); t.ttype != EOF; t = l.getToken() {
+		if t.ttype != FILTER || offset < t.start || offset >= t.end {
+			continue
+		}
+
+		filter, ok := pipeline.Filters[strings.ToLower(t.literal)]
+		if !ok {
+			return nil
+		}
+
+		return lspHover{
+			Contents: lspMarkupText{
+				Kind:  lspMarkdown,
+				Value: fmt.Sprintf("```\n%s\n```\n%s", filter.Definition, filter.Description),
+			},
+			Range: newLSPRange(text, t.position),
+		}
+	}
+
+	return nil
+}
+
+func (s *lspServer) codeActions(uri string, r lspRange) []lspCodeAction {
+	text := s.documents[uri]
+
+	actions := []lspCodeAction{}
+	for _, err := range unwrapErrors(check(text)) {
+		var filterNameError *filterNameError
+		if !errors.As(err, &filterNameError) || filterNameError.suggestion == "" {
+			continue
+		}
+
+		errRange := newLSPRange(text, filterNameError.position)
+		if !errRange.overlaps(r) {
+			continue
+		}
+
+		name := pipeline.Filters[filterNameError.suggestion].Name()
+
+		actions = append(actions, lspCodeAction{
+			Title: fmt.Sprintf("Change to '%s'", name),
+			Kind:  lspQuickFix,
+			Diagnostics: []lspDiagnostic{{
+				Range:    errRange,
+				Severity: lspSeverityError,
+				Source:   lspSource,
+				Message:  err.Error(),
+			}},
+			Edit: lspWorkspaceEdit{
+				Changes: map[string][]lspTextEdit{
+					uri: {{Range: errRange, NewText: name}},
+				},
+			},
+		})
+	}
+
+	return actions
+}
+
+func (s *lspServer) read() (*lspMessage, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, err
+	}
+
+	msg := &lspMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func (s *lspServer) write(msg any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+
+	return err
+}
+
+// newLSPRange converts a byte position in the text to an LSP range of lines
+// and UTF-16 characters.
+func newLSPRange(text string, p position) lspRange {
+	return lspRange{
+		Start: newLSPPosition(text, p.start),
+		End:   newLSPPosition(text, p.end),
+	}
+}
+
+func newLSPPosition(text string, offset int) lspPosition {
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	p := lspPosition{}
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			p.Line++
+			p.Character = 0
+			continue
+		}
+
+		p.Character += utf16Length(r)
+	}
+
+	return p
+}
+
+// lspOffset converts an LSP position to a byte offset in the text.
+func lspOffset(text string, p lspPosition) int {
+	line := 0
+	character := 0
+
+	for i, r := range text {
+		if line == p.Line && character >= p.Character || line > p.Line {
+			return i
+		}
+
+		if r == '\n' {
+			line++
+			character = 0
+			continue
+		}
+
+		character += utf16Length(r)
+	}
+
+	return len(text)
+}
+
+func utf16Length(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+
+	return 1
+}
+
+func (r lspRange) overlaps(o lspRange) bool {
+	return !r.End.before(o.Start) && !o.End.before(r.Start)
+}
+
+func (p lspPosition) before(o lspPosition) bool {
+	return p.Line < o.Line || p.Line == o.Line && p.Character < o.Character
+}
@@ -0,0 +1,182 @@
+package pipesore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type fakeLSPClient struct {
+	t      *testing.T
+	id     int
+	writer io.Writer
+	reader *bufio.Reader
+}
+
+func newFakeLSPClient(t *testing.T) (*fakeLSPClient, chan error) {
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	done := make(chan error)
+	go func() {
+		done <- runLSP(serverReader, serverWriter, "test")
+		serverWriter.Close()
+	}()
+
+	return &fakeLSPClient{t: t, writer: clientWriter, reader: bufio.NewReader(clientReader)}, done
+}
+
+func (c *fakeLSPClient) send(method string, params any, request bool) int {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if request {
+		c.id++
+		msg["id"] = c.id
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+
+	return c.id
+}
+
+func (c *fakeLSPClient) receive(v any) {
+	header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	length, _ := strconv.Atoi(header.Get("Content-Length"))
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		c.t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *fakeLSPClient) request(method string, params any, result any) {
+	id := c.send(method, params, true)
+
+	var response struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+	}
+	c.receive(&response)
+
+	if response.ID != id {
+		c.t.Fatalf("wanted response id: %d, got: %d", id, response.ID)
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func TestLSP(t *testing.T) {
+	t.Parallel()
+
+	uri := "file:///test.pipe"
+	text := "Match(\"a\")\n| Frist(1)\n"
+	document := map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}}
+
+	c, done := newFakeLSPClient(t)
+
+	var initialize struct {
+		ServerInfo struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	c.request("initialize", map[string]any{}, &initialize)
+	if initialize.ServerInfo.Name != "pipesore" {
+		t.Fatalf("wanted server name: pipesore, got: %s", initialize.ServerInfo.Name)
+	}
+
+	c.send("initialized", map[string]any{}, false)
+	c.send("textDocument/didOpen", document, false)
+
+	var diagnostics struct {
+		Method string `json:"method"`
+		Params struct {
+			Diagnostics []lspDiagnostic `json:"diagnostics"`
+		} `json:"params"`
+	}
+	c.receive(&diagnostics)
+
+	wantRange := lspRange{Start: lspPosition{Line: 1, Character: 2}, End: lspPosition{Line: 1, Character: 7}}
+
+	t.Run("diagnostics", func(t *testing.T) {
+		if len(diagnostics.Params.Diagnostics) != 1 {
+			t.Fatalf("wanted 1 diagnostic, got: %v", diagnostics.Params.Diagnostics)
+		}
+
+		if got := diagnostics.Params.Diagnostics[0].Range; !reflect.DeepEqual(wantRange, got) {
+			t.Fatalf("wanted range: %v, got: %v", wantRange, got)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var hover lspHover
+		c.request("textDocument/hover", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     lspPosition{Line: 0, Character: 2},
+		}, &hover)
+
+		if !strings.Contains(hover.Contents.Value, "Returns all lines that contain `substring`.") {
+			t.Fatalf("wanted Match() description, got: %q", hover.Contents.Value)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		var items []lspCompletionItem
+		c.request("textDocument/completion", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     lspPosition{Line: 1, Character: 2},
+		}, &items)
+
+		found := false
+		for _, item := range items {
+			if item.Label == "!First" && item.Detail == "!First(n int)" {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatalf("wanted completion item for '!First', got: %v", items)
+		}
+	})
+
+	t.Run("code action", func(t *testing.T) {
+		var actions []lspCodeAction
+		c.request("textDocument/codeAction", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"range":        wantRange,
+			"context":      map[string]any{"diagnostics": diagnostics.Params.Diagnostics},
+		}, &actions)
+
+		want := []lspTextEdit{{Range: wantRange, NewText: "First"}}
+
+		if len(actions) != 1 || !reflect.DeepEqual(want, actions[0].Edit.Changes[uri]) {
+			t.Fatalf("wanted code action edits: %v, got: %v", want, actions)
+		}
+	})
+
+	var shutdown any
+	c.request("shutdown", nil, &shutdown)
+	c.send("exit", nil, false)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,7 @@
 package pipesore
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -8,6 +9,10 @@ import (
 type parser struct {
 	l *lexer
 	t token
+	// depth is the number of unclosed '(' up to and including the current
+	// token.
+	depth int
+	errs  []error
 }
 
 func newParser(l *lexer) *parser {
@@ -16,9 +21,20 @@ func newParser(l *lexer) *parser {
 
 func (p *parser) nextToken() *parser {
 	p.t = p.l.getToken()
+
+	switch p.t.ttype {
+	case LPAREN:
+		p.depth++
+	case RPAREN:
+		p.depth--
+	}
+
 	return p
 }
 
+// parse recovers from a syntax error by skipping to the next '|' so that all
+// syntax errors in the pipeline are returned (joined) instead of only the
+// first.
 func (p *parser) parse() (*ast, error) {
 	tree := newAST()
 
@@ -26,23 +42,44 @@ func (p *parser) parse() (*ast, error) {
 
 	for {
 		f, err := p.parseFilter()
+		if err == nil {
+			tree.filters = append(tree.filters, *f)
+
+			if !p.nextToken().tokenIsType(EOF) {
+				err = p.tokenMustType(PIPE)
+			}
+		}
 		if err != nil {
-			return nil, err
+			p.errs = append(p.errs, err)
+			p.skipFilter()
 		}
 
-		tree.filters = append(tree.filters, *f)
-
-		if p.nextToken().tokenIsType(EOF) {
+		if p.tokenIsType(EOF) {
 			break
 		}
-		err = p.tokenMustType(PIPE)
-		if err != nil {
-			return nil, err
+		p.nextToken()
+	}
+
+	return tree, errors.Join(p.errs...)
+}
+
+func (p *parser) skipFilter() {
+	for !p.tokenIsTypes(PIPE, EOF) {
+		p.nextToken()
+	}
+}
+
+// skipArgument skips to the ',' or ')' ending the argument started at 'depth'
+// and reports if one was found before the end of the pipeline.
+func (p *parser) skipArgument(depth int) bool {
+	for !p.tokenIsType(EOF) {
+		if (p.depth == depth && p.tokenIsType(COMMA)) || p.depth < depth {
+			return true
 		}
 		p.nextToken()
 	}
 
-	return tree, nil
+	return false
 }
 
 func (p *parser) tokenIsType(tt tokenType) bool {
@@ -122,19 +159,33 @@ func (p *parser) parseArguments() ([]any, error) {
 	}
 
 	for {
-		err := p.tokenMustTypes(STRING, INT)
+		err := p.tokenMustTypes(STRING, INT, FLOAT, FILTER)
 		if err != nil {
 			return nil, err
 		}
 
-		if p.tokenIsType(INT) {
-			i, _ := strconv.Atoi(p.t.literal)
-			args = append(args, i)
+		if p.tokenIsType(FILTER) {
+			tree, err := p.parsePipeline()
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, tree)
 		} else {
-			args = append(args, p.t.literal)
+			if p.tokenIsType(INT) {
+				i, _ := strconv.Atoi(p.t.literal)
+				args = append(args, i)
+			} else if p.tokenIsType(FLOAT) {
+				f, _ := strconv.ParseFloat(p.t.literal, 64)
+				args = append(args, f)
+			} else {
+				args = append(args, p.t.literal)
+			}
+
+			p.nextToken()
 		}
 
-		if p.nextToken().tokenIsType(RPAREN) {
+		if p.tokenIsType(RPAREN) {
 			break
 		}
 		err = p.tokenMustType(COMMA)
@@ -146,3 +197,32 @@ func (p *parser) parseArguments() ([]any, error) {
 
 	return args, nil
 }
+
+// parsePipeline parses a pipeline given as a filter argument, such as
+// 'Frequency() | First(5)' in 'Fork(CountLines(), Frequency() | First(5))',
+// leaving the token after it as the current token. A syntax error in the
+// pipeline is recovered from by skipping to the end of the argument so that
+// only the one error is returned for it.
+func (p *parser) parsePipeline() (*ast, error) {
+	tree := newAST()
+	depth := p.depth
+
+	for {
+		f, err := p.parseFilter()
+		if err != nil {
+			if !p.skipArgument(depth) {
+				return nil, err
+			}
+
+			p.errs = append(p.errs, err)
+			return tree, nil
+		}
+
+		tree.filters = append(tree.filters, *f)
+
+		if !p.nextToken().tokenIsType(PIPE) {
+			return tree, nil
+		}
+		p.nextToken()
+	}
+}
@@ -1,6 +1,7 @@
 package pipesore
 
 import (
+	"fmt"
 	"log"
 	"reflect"
 	"testing"
@@ -29,4 +30,101 @@ func TestParse(t *testing.T) {
 			t.Fatalf("\nwanted:\n\n%#v\n\ngot:\n\n%#v\n\n", want, got)
 		}
 	})
+
+	t.Run("nested pipelines", func(t *testing.T) {
+		filters := `Fork(CountLines(), Match("a") | First(0.5))`
+
+		want := &ast{
+			filters: []filter{
+				{name: "Fork", arguments: []any{
+					&ast{filters: []filter{
+						{name: "CountLines", arguments: nil, position: position{start: 5, end: 15}},
+					}},
+					&ast{filters: []filter{
+						{name: "Match", arguments: []any{"a"}, position: position{start: 19, end: 24}},
+						{name: "First", arguments: []any{0.5}, position: position{start: 32, end: 37}},
+					}},
+				}, position: position{start: 0, end: 4}},
+			},
+		}
+
+		got, err := newParser(newLexer(filters)).parse()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("\nwanted:\n\n%#v\n\ngot:\n\n%#v\n\n", want, got)
+		}
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	filters := `First(1 | Freq() Last(1) | Match("a") |`
+
+	wantFilters := []filter{
+		{name: "Freq", arguments: nil, position: position{start: 10, end: 14}},
+		{name: "Match", arguments: []any{"a"}, position: position{start: 27, end: 32}},
+	}
+
+	wantErrors := []position{
+		{start: 8, end: 9},
+		{start: 17, end: 21},
+		{start: 39, end: 40},
+	}
+
+	got, err := newParser(newLexer(filters)).parse()
+
+	if !reflect.DeepEqual(wantFilters, got.filters) {
+		t.Fatalf("\nwanted:\n\n%#v\n\ngot:\n\n%#v\n\n", wantFilters, got.filters)
+	}
+
+	gotErrors := []position{}
+	for _, err := range unwrapErrors(err) {
+		position, _ := errorPosition(err)
+		gotErrors = append(gotErrors, position)
+	}
+
+	if !reflect.DeepEqual(wantErrors, gotErrors) {
+		t.Fatalf("wanted error positions: %v, got: %v", wantErrors, gotErrors)
+	}
+}
+
+func TestParseErrorsInArguments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  []position
+	}{
+		{`Fork(CountLines(1 2) | Frequency(), First(1)) | Match("a")`, []position{{start: 18, end: 19}}},
+		{`Fork(CountLines(1 2) | Frequency(), First(1 2)) | Match(`, []position{{start: 18, end: 19}, {start: 44, end: 45}, {start: 56, end: 57}}},
+		{`Fork(Upper() | , Lower()) | Upper()`, []position{{start: 15, end: 16}}},
+		{`Fork(Fork(Upper(1 2), Lower()), Lower()) | Upper()`, []position{{start: 18, end: 19}}},
+		{`Fork(CountLines(1 2) | Frequency()`, []position{{start: 18, end: 19}}},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newParser(newLexer(tc.input)).parse()
+
+			got := []position{}
+			for _, err := range unwrapErrors(err) {
+				position, _ := errorPosition(err)
+				got = append(got, position)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("(test %d) wanted error positions: %v, got: %v: %v", k, tc.want, got, err)
+			}
+		})
+	}
 }
@@ -8,6 +8,7 @@ const (
 
 	FILTER // First
 	INT    // 1234
+	FLOAT  // 0.01
 	STRING // hello, world!
 
 	QUOTE // "
@@ -25,6 +26,7 @@ var tokens = [...]string{
 
 	FILTER: "FILTER",
 	INT:    "INT",
+	FLOAT:  "FLOAT",
 	STRING: "STRING",
 
 	QUOTE: "\"",
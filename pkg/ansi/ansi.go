@@ -0,0 +1,81 @@
+package ansi
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	Red       = "\x1b[31m"
+	Green     = "\x1b[32m"
+	Yellow    = "\x1b[33m"
+	Blue      = "\x1b[34m"
+	Magenta   = "\x1b[35m"
+	Cyan      = "\x1b[36m"
+	Bold      = "\x1b[1m"
+	Undercurl = "\x1b[4:3m"
+	Reset     = "\x1b[0m"
+)
+
+// Colors are the colours cycled through when colouring multiple things.
+var Colors = []string{Red, Green, Yellow, Blue, Magenta, Cyan}
+
+// Enabled returns true if colours should be written to f, that is if the
+// NO_COLOR environment variable isn't set (https://no-color.org) and f is a
+// terminal.
+func Enabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Escapes returns the start and end byte offsets of the escape sequences in s.
+// Only Control Sequence Introducer (CSI) sequences, which include colours, are
+// recognised.
+func Escapes(s string) [][2]int {
+	escapes := [][2]int{}
+
+	for i := 0; i < len(s); {
+		start := strings.Index(s[i:], "\x1b[")
+		if start == -1 {
+			break
+		}
+
+		start += i
+
+		// parameter and intermediate bytes are followed by a single final byte
+		// in the range 0x40 to 0x7e
+		end := start + 2
+		for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+			end++
+		}
+
+		end = min(end+1, len(s))
+		escapes = append(escapes, [2]int{start, end})
+		i = end
+	}
+
+	return escapes
+}
+
+// Strip returns s without escape sequences.
+func Strip(s string) string {
+	var b strings.Builder
+
+	i := 0
+	for _, e := range Escapes(s) {
+		b.WriteString(s[i:e[0]])
+		i = e[1]
+	}
+
+	b.WriteString(s[i:])
+
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package ansi
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  [][2]int
+	}{
+		{"", [][2]int{}},
+		{"abc", [][2]int{}},
+		{Red + "a" + Reset, [][2]int{{0, 5}, {6, 10}}},
+		{"a" + Undercurl + "b", [][2]int{{1, 7}}},
+		{"a\x1b[2Kb", [][2]int{{1, 5}}},
+		{"a\x1b[31", [][2]int{{1, 5}}},
+		{"a\x1bb", [][2]int{}},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := Escapes(tc.input)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				log.Fatalf("(test %d) wanted: %v, got: %v", k, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"abc", "abc"},
+		{Red + "a" + Reset + "b", "ab"},
+		{Bold + Green + "a" + Undercurl + "b" + Reset, "ab"},
+		{"a\x1b[2Kb", "ab"},
+		{"a\x1b[31", "a"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := Strip(tc.input)
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got)
+			}
+		})
+	}
+}
+
+// TestEnabled isn't parallel as it sets the NO_COLOR environment variable.
+func TestEnabled(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	closed, err := os.Create(filepath.Join(t.TempDir(), "closed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	// a character device is treated as a terminal
+	device, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	tests := []struct {
+		noColor string
+		file    *os.File
+		want    bool
+	}{
+		{"", f, false},
+		{"1", f, false},
+		{"", closed, false},
+		{"", device, true},
+		{"1", device, false},
+	}
+
+	for k, tc := range tests {
+		t.Setenv("NO_COLOR", tc.noColor)
+
+		if got := Enabled(tc.file); tc.want != got {
+			t.Fatalf("(test %d) wanted: %v, got: %v", k, tc.want, got)
+		}
+	}
+}
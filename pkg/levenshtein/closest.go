@@ -0,0 +1,19 @@
+package levenshtein
+
+// Closest returns the candidate with the smallest distance to s. Candidates
+// that are as different to s as its length are never returned so an empty
+// string is returned if there is no close candidate. Ties are broken by the
+// order of the candidates.
+func Closest(s string, candidates []string) string {
+	lowestScore := len(s)
+	closest := ""
+	for _, candidate := range candidates {
+		distance := Distance(s, candidate)
+		if distance < lowestScore {
+			lowestScore = distance
+			closest = candidate
+		}
+	}
+
+	return closest
+}
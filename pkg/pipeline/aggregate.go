@@ -0,0 +1,435 @@
+package pipeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An aggregator combines a stream of numbers into a single value.
+type aggregator interface {
+	add(x float64)
+	// value returns the aggregate or false if there is no aggregate.
+	value() (float64, bool)
+}
+
+type sum struct {
+	total float64
+}
+
+func (s *sum) add(x float64) {
+	s.total += x
+}
+
+func (s *sum) value() (float64, bool) {
+	return s.total, true
+}
+
+type minimum struct {
+	min float64
+	n   int
+}
+
+func (m *minimum) add(x float64) {
+	if m.n == 0 || x < m.min {
+		m.min = x
+	}
+	m.n++
+}
+
+func (m *minimum) value() (float64, bool) {
+	return m.min, m.n > 0
+}
+
+type maximum struct {
+	max float64
+	n   int
+}
+
+func (m *maximum) add(x float64) {
+	if m.n == 0 || x > m.max {
+		m.max = x
+	}
+	m.n++
+}
+
+func (m *maximum) value() (float64, bool) {
+	return m.max, m.n > 0
+}
+
+type mean struct {
+	total float64
+	n     int
+}
+
+func (m *mean) add(x float64) {
+	m.total += x
+	m.n++
+}
+
+func (m *mean) value() (float64, bool) {
+	return m.total / float64(m.n), m.n > 0
+}
+
+// aggregate returns a filter that writes the aggregate of the numbers read.
+// Numbers are read one per line, or from the 1-indexed 'column' defined by
+// splitting with the 'delimiter' if 'column' is greater than 0. Lines that
+// aren't numbers are handled by the 'invalid' policy.
+func aggregate(delimiter string, column int, invalid InvalidPolicy, newAggregator func() (aggregator, error)) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if invalid == PassInvalid {
+			return errors.New("invalid policy must be one of \"skip\" or \"error\", got: \"pass\"")
+		}
+
+		a, err := newAggregator()
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			x, err := parseNumber(scanner.Text(), delimiter, column)
+			if err != nil {
+				if err := invalid.handle(w, n, scanner.Text(), err); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			a.add(x)
+		}
+
+		if v, ok := a.value(); ok {
+			fmt.Fprintln(w, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+
+		return scanner.Err()
+	}
+}
+
+// aggregateColumn returns an aggregate filter of the 1-indexed 'column', which
+// must be positive.
+func aggregateColumn(delimiter string, column int, invalid InvalidPolicy, newAggregator func() (aggregator, error)) func(io.Reader, io.Writer) error {
+	if column < 1 {
+		return func(io.Reader, io.Writer) error {
+			return fmt.Errorf("column must be a positive int, got: %d", column)
+		}
+	}
+
+	return aggregate(delimiter, column, invalid, newAggregator)
+}
+
+// parseNumber returns the number in 'line', or in the 1-indexed 'column' of
+// 'line' defined by splitting with the 'delimiter' if 'column' is greater than
+// 0.
+func parseNumber(line, delimiter string, column int) (float64, error) {
+	if column > 0 {
+		lineColumns := strings.Split(line, delimiter)
+		if column > len(lineColumns) {
+			return 0, fmt.Errorf("missing column %d", column)
+		}
+
+		line = lineColumns[column-1]
+	}
+
+	x, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+	if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0, fmt.Errorf("not a number: %q", line)
+	}
+
+	return x, nil
+}
+
+// newPercentile returns a constructor for an aggregator of the estimated
+// 'p'th percentile.
+func newPercentile(p int) func() (aggregator, error) {
+	return func() (aggregator, error) {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile must be between 0 and 100, got: %d", p)
+		}
+
+		return newQuantile(float64(p) / 100), nil
+	}
+}
+
+// Sum returns a filter that writes the sum of the numbers read, one per line.
+// Lines that aren't numbers are handled by the 'invalid' policy.
+func Sum(invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, func() (aggregator, error) { return &sum{}, nil })
+}
+
+// SumColumn returns a filter that writes the sum of the numbers read from the
+// 1-indexed 'column' defined by splitting with the 'delimiter'. Lines where the
+// column isn't a number are handled by the 'invalid' policy.
+func SumColumn(delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, func() (aggregator, error) { return &sum{}, nil })
+}
+
+// Min returns a filter that writes the minimum of the numbers read, one per
+// line. Lines that aren't numbers are handled by the 'invalid' policy.
+func Min(invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, func() (aggregator, error) { return &minimum{}, nil })
+}
+
+// MinColumn returns a filter that writes the minimum of the numbers read from
+// the 1-indexed 'column' defined by splitting with the 'delimiter'. Lines where
+// the column isn't a number are handled by the 'invalid' policy.
+func MinColumn(delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, func() (aggregator, error) { return &minimum{}, nil })
+}
+
+// Max returns a filter that writes the maximum of the numbers read, one per
+// line. Lines that aren't numbers are handled by the 'invalid' policy.
+func Max(invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, func() (aggregator, error) { return &maximum{}, nil })
+}
+
+// MaxColumn returns a filter that writes the maximum of the numbers read from
+// the 1-indexed 'column' defined by splitting with the 'delimiter'. Lines where
+// the column isn't a number are handled by the 'invalid' policy.
+func MaxColumn(delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, func() (aggregator, error) { return &maximum{}, nil })
+}
+
+// Mean returns a filter that writes the mean of the numbers read, one per line.
+// Lines that aren't numbers are handled by the 'invalid' policy.
+func Mean(invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, func() (aggregator, error) { return &mean{}, nil })
+}
+
+// MeanColumn returns a filter that writes the mean of the numbers read from the
+// 1-indexed 'column' defined by splitting with the 'delimiter'. Lines where the
+// column isn't a number are handled by the 'invalid' policy.
+func MeanColumn(delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, func() (aggregator, error) { return &mean{}, nil })
+}
+
+// Median returns a filter that writes the estimated median of the numbers
+// read, one per line. Lines that aren't numbers are handled by the 'invalid'
+// policy.
+func Median(invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, newPercentile(50))
+}
+
+// MedianColumn returns a filter that writes the estimated median of the
+// numbers read from the 1-indexed 'column' defined by splitting with the
+// 'delimiter'. Lines where the column isn't a number are handled by the
+// 'invalid' policy.
+func MedianColumn(delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, newPercentile(50))
+}
+
+// Percentile returns a filter that writes the estimated 'p'th percentile of the
+// numbers read, one per line. Lines that aren't numbers are handled by the
+// 'invalid' policy.
+func Percentile(p int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregate("", 0, invalid, newPercentile(p))
+}
+
+// PercentileColumn returns a filter that writes the estimated 'p'th percentile
+// of the numbers read from the 1-indexed 'column' defined by splitting with the
+// 'delimiter'. Lines where the column isn't a number are handled by the
+// 'invalid' policy.
+func PercentileColumn(p int, delimiter string, column int, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return aggregateColumn(delimiter, column, invalid, newPercentile(p))
+}
+
+type counter struct {
+	n int
+}
+
+func (c *counter) add(float64) {
+	c.n++
+}
+
+func (c *counter) value() (float64, bool) {
+	return float64(c.n), true
+}
+
+// An aggregateSpec is a parsed aggregate from a GroupBy list of aggregates.
+type aggregateSpec struct {
+	spec          string
+	column        int
+	newAggregator func() aggregator
+}
+
+// parseAggregates returns the aggregates in 'aggregates', a comma separated
+// list of "count" or "function:column" where function is one of sum, min, max,
+// mean, median or pN for the Nth percentile and column is 1-indexed.
+func parseAggregates(aggregates string) ([]aggregateSpec, error) {
+	specs := []aggregateSpec{}
+	for _, spec := range strings.Split(aggregates, ",") {
+		spec = strings.TrimSpace(spec)
+
+		if spec == "count" {
+			specs = append(specs, aggregateSpec{spec, 0, func() aggregator { return &counter{} }})
+			continue
+		}
+
+		function, c, ok := strings.Cut(spec, ":")
+		column, err := strconv.Atoi(c)
+		if !ok || err != nil || column < 1 {
+			return nil, fmt.Errorf("aggregate must be \"count\" or \"function:column\", got: %q", spec)
+		}
+
+		var newAggregator func() aggregator
+		switch function {
+		case "sum":
+			newAggregator = func() aggregator { return &sum{} }
+		case "min":
+			newAggregator = func() aggregator { return &minimum{} }
+		case "max":
+			newAggregator = func() aggregator { return &maximum{} }
+		case "mean":
+			newAggregator = func() aggregator { return &mean{} }
+		case "median":
+			newAggregator = func() aggregator { return newQuantile(0.5) }
+		default:
+			p, err := strconv.Atoi(strings.TrimPrefix(function, "p"))
+			if !strings.HasPrefix(function, "p") || err != nil || p < 0 || p > 100 {
+				return nil, fmt.Errorf("aggregate function must be one of count, sum, min, max, mean, median or p0 to p100, got: %q", function)
+			}
+
+			newAggregator = func() aggregator { return newQuantile(float64(p) / 100) }
+		}
+
+		specs = append(specs, aggregateSpec{spec, column, newAggregator})
+	}
+
+	return specs, nil
+}
+
+// GroupBy returns a filter that writes a line for each unique key of the
+// 'keys' columns followed by the 'aggregates' of the lines with that key.
+// 'keys' is a 1-indexed comma separated list of column positions and
+// 'aggregates' is a comma separated list of "count" or "function:column" where
+// function is one of sum, min, max, mean, median or pN for the Nth percentile.
+// Columns are defined by splitting with the 'delimiter'. Lines are sorted
+// alphabetically by key.
+func GroupBy(delimiter, keys, aggregates string) func(io.Reader, io.Writer) error {
+	return groupBy(delimiter, keys, aggregates, "")
+}
+
+// GroupBySort returns a filter like GroupBy with lines sorted by the 'sortBy'
+// aggregate in descending numerical order (largest first). Lines with equal
+// aggregates will be sorted alphabetically by key.
+func GroupBySort(delimiter, keys, aggregates, sortBy string) func(io.Reader, io.Writer) error {
+	return groupBy(delimiter, keys, aggregates, sortBy)
+}
+
+func groupBy(delimiter, keys, aggregates, sortBy string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		keyColumns, err := parseColumns(keys)
+		if err != nil {
+			return err
+		}
+
+		specs, err := parseAggregates(aggregates)
+		if err != nil {
+			return err
+		}
+
+		sortIndex := -1
+		if sortBy != "" {
+			for i, spec := range specs {
+				if spec.spec == strings.TrimSpace(sortBy) {
+					sortIndex = i
+				}
+			}
+
+			if sortIndex == -1 {
+				return fmt.Errorf("sort aggregate %q must be one of the aggregates, got: %q", sortBy, aggregates)
+			}
+		}
+
+		type group struct {
+			key        []string
+			aggregates []aggregator
+			values     []float64
+		}
+
+		groups := map[string]*group{}
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			lineColumns := Record(strings.Split(scanner.Text(), delimiter))
+
+			key := []string{}
+			for _, column := range keyColumns {
+				key = append(key, lineColumns.field(column))
+			}
+
+			// join with a NUL as the delimiter may be empty
+			g, ok := groups[strings.Join(key, "\x00")]
+			if !ok {
+				g = &group{key: key}
+				for _, spec := range specs {
+					g.aggregates = append(g.aggregates, spec.newAggregator())
+				}
+
+				groups[strings.Join(key, "\x00")] = g
+			}
+
+			for i, spec := range specs {
+				var x float64
+				if spec.column > 0 {
+					x, err = parseNumber(scanner.Text(), delimiter, spec.column)
+					if err != nil {
+						return fmt.Errorf("invalid line %d: %w", n, err)
+					}
+				}
+
+				g.aggregates[i].add(x)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		sorted := make([]*group, 0, len(groups))
+		for _, g := range groups {
+			for _, a := range g.aggregates {
+				v, _ := a.value()
+				g.values = append(g.values, v)
+			}
+
+			sorted = append(sorted, g)
+		}
+
+		sort.Slice(sorted, func(i, j int) bool {
+			x, y := sorted[i], sorted[j]
+
+			if sortIndex != -1 && x.values[sortIndex] != y.values[sortIndex] {
+				return x.values[sortIndex] > y.values[sortIndex]
+			}
+
+			return slices.Compare(x.key, y.key) < 0
+		})
+
+		for _, g := range sorted {
+			line := append([]string{}, g.key...)
+			for _, v := range g.values {
+				line = append(line, strconv.FormatFloat(v, 'f', -1, 64))
+			}
+
+			fmt.Fprintln(w, strings.Join(line, delimiter))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestAggregateFilters(t *testing.T) {
+	t.Parallel()
+
+	thousand := ""
+	for i := 1000; i > 0; i-- {
+		thousand += fmt.Sprintln(i)
+	}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Sum(SkipInvalid), "", "0\n"},
+		{Sum(SkipInvalid), "1\n2.5\n x \n-0.5\n", "3\n"},
+		{SumColumn(",", 2, SkipInvalid), "a,1\nb,2\nc\nd,3\n", "6\n"},
+		{Min(SkipInvalid), "", ""},
+		{Min(SkipInvalid), "3\n-1\n2\n", "-1\n"},
+		{MinColumn(" ", 1, SkipInvalid), "3 a\n1 b\n", "1\n"},
+		{Max(SkipInvalid), "3\n-1\n2\n", "3\n"},
+		{MaxColumn(" ", 1, SkipInvalid), "3 a\n10 b\n", "10\n"},
+		{Mean(SkipInvalid), "", ""},
+		{Mean(SkipInvalid), "1\n2\n3\n4\n", "2.5\n"},
+		{MeanColumn(",", 2, ErrorInvalid), "a,1\nb,2\n", "1.5\n"},
+		{Median(SkipInvalid), "3\n1\n2\n", "2\n"},
+		{Median(SkipInvalid), "4\n1\n3\n2\n", "2.5\n"},
+		{MedianColumn(",", 2, SkipInvalid), "a,5\nb,1\nc,3\n", "3\n"},
+		{Percentile(0, SkipInvalid), "3\n1\n2\n", "1\n"},
+		{Percentile(100, SkipInvalid), "3\n1\n2\n", "3\n"},
+		{Percentile(0, SkipInvalid), thousand, "1\n"},
+		{Percentile(100, SkipInvalid), thousand, "1000\n"},
+		{PercentileColumn(50, ",", 1, SkipInvalid), "1,a\n1,b\n", "1\n"},
+		{GroupBy(",", "1", "count"), "", ""},
+		{GroupBy(",", "1", "count,sum:2,max:3"), "b,1,5\na,2,3\nb,3,1\n", "a,1,2,3\nb,2,4,5\n"},
+		{GroupBy(" ", "2,1", "mean:3, median:3"), "x a 1\nx a 2\ny a 6\n", "a x 1.5 1.5\na y 6 6\n"},
+		{GroupBySort(",", "1", "count,sum:2", "sum:2"), "a,1\nb,5\na,2\nc,5\n", "b,1,5\nc,1,5\na,2,3\n"},
+		{GroupBySort(",", "1", "count", "count"), "b\na\nb\nc\n", "b,2\na,1\nc,1\n"},
+		{GroupBySort(",", "1,2", "p95:3", "p95:3"), "a,b,1\na,c,2\n", "a,c,2\na,b,1\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestAggregateFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Sum(ErrorInvalid), "1\nx\n", "invalid line 2: not a number: \"x\""},
+		{SumColumn(",", 2, ErrorInvalid), "a,1\nb\n", "invalid line 2: missing column 2"},
+		{Mean(ErrorInvalid), "NaN\n", "invalid line 1: not a number: \"NaN\""},
+		{Sum(PassInvalid), "1\n", "invalid policy must be one of \"skip\" or \"error\", got: \"pass\""},
+		{Percentile(101, SkipInvalid), "1\n", "percentile must be between 0 and 100, got: 101"},
+		{SumColumn(",", 0, SkipInvalid), "1\n", "column must be a positive int, got: 0"},
+		{PercentileColumn(50, ",", -1, SkipInvalid), "1\n", "column must be a positive int, got: -1"},
+		{GroupBy(",", "1", "sum:2"), "a,1\nb\n", "invalid line 2: missing column 2"},
+		{GroupBy(",", "1", "sum"), "a,1\n", "aggregate must be \"count\" or \"function:column\", got: \"sum\""},
+		{GroupBy(",", "1", "total:2"), "a,1\n", "aggregate function must be one of count, sum, min, max, mean, median or p0 to p100, got: \"total\""},
+		{GroupBySort(",", "1", "count", "sum:2"), "a,1\n", "sort aggregate \"sum:2\" must be one of the aggregates, got: \"count\""},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader(tc.input), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 0},
+		{0.5, 50000},
+		{0.9, 90000},
+		{0.99, 99000},
+		{1, 99999},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			// a shuffled uniform sequence so the estimate can be checked
+			// against the known quantile
+			q := newQuantile(tc.p)
+			for _, x := range rand.New(rand.NewSource(1)).Perm(100000) {
+				q.add(float64(x))
+			}
+
+			got, ok := q.value()
+			if !ok || math.Abs(got-tc.want) > 1000 {
+				log.Fatalf("(test %d) wanted: %v (±1000), got: %v", k, tc.want, got)
+			}
+		})
+	}
+}
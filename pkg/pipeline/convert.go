@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dyson/pipesore/pkg/textwidth"
+)
+
+// CSVToJSON returns a CSV aware filter that reads the first line as a header
+// and writes each following line as a JSON object with the header columns as
+// keys.
+func CSVToJSON(delimiter string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		reader := newCSVReader(r, delimiter)
+		reader.FieldsPerRecord = -1
+
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for {
+			lineColumns, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			o := newJSONObject()
+			for i, key := range header {
+				value := ""
+				if i < len(lineColumns) {
+					value = lineColumns[i]
+				}
+
+				o.set(key, value)
+			}
+
+			fmt.Fprintln(w, encodeJSON(o))
+		}
+
+		return nil
+	}
+}
+
+// JSONToCSV returns a filter that writes a CSV header of 'paths' followed by
+// the values selected by 'paths' from each line of JSON separated by
+// 'delimiter'.
+func JSONToCSV(paths []*JSONPath, delimiter string, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		writer := csv.NewWriter(w)
+		writer.Comma, _ = utf8.DecodeRuneInString(delimiter)
+		defer writer.Flush()
+
+		header := []string{}
+		for _, path := range paths {
+			header = append(header, strings.TrimPrefix(path.String(), "."))
+		}
+
+		writer.Write(header)
+		writer.Flush()
+
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			columns := []string{}
+			for _, path := range paths {
+				column := ""
+				if v, ok := path.get(doc); ok {
+					column = jsonText(v)
+				}
+
+				columns = append(columns, column)
+			}
+
+			writer.Write(columns)
+
+			// flush so lines passed through by the invalid policy are written
+			// in order
+			writer.Flush()
+
+			return nil
+		})
+	}
+}
+
+// TSVToCSV returns a filter that writes each line of tab separated columns as
+// a line of CSV.
+func TSVToCSV() func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			writer.Write(strings.Split(scanner.Text(), "\t"))
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Table returns a filter that writes lines of columns defined by splitting with
+// the 'delimiter' aligned into a table. Columns are left aligned, padded to
+// the display width of the widest value and separated by two spaces.
+func Table(delimiter string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		rows := [][]string{}
+		widths := []int{}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			lineColumns := strings.Split(scanner.Text(), delimiter)
+
+			for i, column := range lineColumns {
+				if i == len(widths) {
+					widths = append(widths, 0)
+				}
+
+				widths[i] = max(widths[i], textwidth.String(column))
+			}
+
+			rows = append(rows, lineColumns)
+		}
+
+		for _, lineColumns := range rows {
+			for i, column := range lineColumns {
+				if i == len(lineColumns)-1 {
+					fmt.Fprintln(w, column)
+					break
+				}
+
+				fmt.Fprint(w, padRight(column, widths[i]), "  ")
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// padLeft returns 's' left padded with spaces to the display 'width'.
+func padLeft(s string, width int) string {
+	return strings.Repeat(" ", max(0, width-textwidth.String(s))) + s
+}
+
+// padRight returns 's' right padded with spaces to the display 'width'.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-textwidth.String(s)))
+}
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestConvertFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{CSVToJSON(","), "", ""},
+		{CSVToJSON(","), "b,a\n1,\"x,<y>\"\n2\n", "{\"b\":\"1\",\"a\":\"x,<y>\"}\n{\"b\":\"2\",\"a\":\"\"}\n"},
+		{CSVToJSON("\t"), "a\tb\n1\t2\n", "{\"a\":\"1\",\"b\":\"2\"}\n"},
+		{JSONToCSV([]*JSONPath{mustParseJSONPath(".a"), mustParseJSONPath(".b.c")}, ",", SkipInvalid), "{\"a\":\"x,y\",\"b\":{\"c\":1}}\nbad\n{\"a\":2}\n", "a,b.c\n\"x,y\",1\n2,\n"},
+		{JSONToCSV([]*JSONPath{mustParseJSONPath(".a")}, ",", PassInvalid), "{\"a\":1}\nbad\n{\"a\":2}\n", "a\n1\nbad\n2\n"},
+		{TSVToCSV(), "a\tb,c\n\"d\"\t\n", "a,\"b,c\"\n\"\"\"d\"\"\",\n"},
+		{Table(","), "a,bb,c\nccc,d\n", "a    bb  c\nccc  d\n"},
+		{Table(","), "🍎,x\nab,y\n", "🍎  x\nab  y\n"},
+		{Table(","), "日本語,x\nab,y\n", "日本語  x\nab      y\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
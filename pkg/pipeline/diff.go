@@ -0,0 +1,321 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+)
+
+// diffContext is the number of unchanged lines around each change in a hunk.
+const diffContext = 3
+
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+// An edit keeps, deletes or inserts a line. 'a' and 'b' are the positions in
+// each input before the edit.
+type edit struct {
+	kind editKind
+	a, b int
+}
+
+// diffLines returns the shortest edit script from 'a' to 'b' using the linear
+// space variant of the Myers O(ND) algorithm, so memory grows with the size of
+// the inputs rather than the square of the number of differences. Deletions
+// come before insertions in each change, as in the output of diff -u.
+func diffLines(a, b []string) []edit {
+	d := &differ{a: a, b: b, edits: []edit{}}
+	d.diff(0, len(a), 0, len(b))
+
+	return deletionsFirst(d.edits)
+}
+
+// deletionsFirst returns 'edits' with the deletions of each run of changes
+// before its insertions.
+func deletionsFirst(edits []edit) []edit {
+	for i := 0; i < len(edits); {
+		if edits[i].kind == editEqual {
+			i++
+			continue
+		}
+
+		start := i
+		deletes := 0
+		for i < len(edits) && edits[i].kind != editEqual {
+			if edits[i].kind == editDelete {
+				deletes++
+			}
+			i++
+		}
+
+		a, b := edits[start].a, edits[start].b
+		for j := start; j < i; j++ {
+			a, b = min(a, edits[j].a), min(b, edits[j].b)
+		}
+
+		for j := start; j < i; j++ {
+			if j-start < deletes {
+				edits[j] = edit{editDelete, a + j - start, b}
+			} else {
+				edits[j] = edit{editInsert, a + deletes, b + j - start - deletes}
+			}
+		}
+	}
+
+	return edits
+}
+
+// A differ appends the edits between ranges of 'a' and 'b' in order.
+type differ struct {
+	a, b  []string
+	edits []edit
+}
+
+// diff appends the edits from a[aLo:aHi] to b[bLo:bHi].
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, edit{editEqual, aLo, bLo})
+		aLo++
+		bLo++
+	}
+
+	// the common suffix is appended after the edits before it
+	suffix := 0
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, edit{editInsert, aLo, y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, edit{editDelete, x, bLo})
+		}
+	default:
+		x, y, ok := d.split(aLo, aHi, bLo, bHi)
+		if !ok {
+			// nothing in common
+			for x := aLo; x < aHi; x++ {
+				d.edits = append(d.edits, edit{editDelete, x, bLo})
+			}
+			for y := bLo; y < bHi; y++ {
+				d.edits = append(d.edits, edit{editInsert, aHi, y})
+			}
+
+			break
+		}
+
+		d.diff(aLo, x, bLo, y)
+		d.diff(x, aHi, y, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, edit{editEqual, aHi + i, bHi + i})
+	}
+}
+
+// split returns a point on a shortest edit script from a[aLo:aHi] to
+// b[bLo:bHi], found where the furthest reaching paths from the start and from
+// the end meet (the middle snake). It returns false if the ranges have nothing
+// in common.
+func (d *differ) split(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+
+	maxD := (n + m + 1) / 2
+	offset := maxD
+
+	// the furthest x reached on each diagonal k from the start (forward) and,
+	// counting back, from the end (backward); -1 if not reached
+	forward := make([]int, 2*maxD+1)
+	backward := make([]int, 2*maxD+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+
+	// with an odd delta the paths meet after a forward step, otherwise after
+	// a backward step
+	odd := delta%2 != 0
+
+	// diagonals that have left the edit graph aren't extended again
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var x int
+			if k == -step || k != step && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				i := offset + delta - k
+				if i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return aLo + x, bLo + y, true
+				}
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			var x int
+			if k == -step || k != step && backward[offset+k-1] < backward[offset+k+1] {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				i := offset + delta - k
+				if i >= 0 && i < len(forward) && forward[i] != -1 && forward[i] >= n-x {
+					return aLo + forward[i], bLo + forward[i] - (delta - k), true
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Diff returns a filter that writes the differences between the input and the
+// 'file' in unified format, with 3 lines of context around each change.
+// Nothing is written if they are the same. The input is named "-" unless it's
+// a single file read by the first filter in a pipeline.
+func Diff(file File) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		a, err := readLines(r)
+		if err != nil {
+			return err
+		}
+
+		b, err := readLines(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", file.Name, err)
+		}
+
+		edits := diffLines(a, b)
+
+		header := false
+
+		i := 0
+		for i < len(edits) {
+			for i < len(edits) && edits[i].kind == editEqual {
+				i++
+			}
+
+			if i == len(edits) {
+				break
+			}
+
+			start := max(i-diffContext, 0)
+
+			// extend the hunk while the unchanged lines between changes would
+			// overlap their context
+			end := i
+			for {
+				for end < len(edits) && edits[end].kind != editEqual {
+					end++
+				}
+
+				next := end
+				for next < len(edits) && edits[next].kind == editEqual {
+					next++
+				}
+
+				if next == len(edits) || next-end > 2*diffContext {
+					end = min(end+diffContext, len(edits))
+					break
+				}
+
+				end = next
+			}
+
+			if !header {
+				fmt.Fprintf(w, "--- %s\n+++ %s\n", inputName(r), file.Name)
+				header = true
+			}
+
+			writeHunk(w, a, b, edits[start:end])
+
+			i = end
+		}
+
+		return nil
+	}
+}
+
+func writeHunk(w io.Writer, a, b []string, edits []edit) {
+	aCount, bCount := 0, 0
+	for _, e := range edits {
+		if e.kind != editInsert {
+			aCount++
+		}
+		if e.kind != editDelete {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(edits[0].a, aCount), hunkRange(edits[0].b, bCount))
+
+	for _, e := range edits {
+		switch e.kind {
+		case editEqual:
+			fmt.Fprintln(w, " "+a[e.a])
+		case editDelete:
+			fmt.Fprintln(w, "-"+a[e.a])
+		case editInsert:
+			fmt.Fprintln(w, "+"+b[e.b])
+		}
+	}
+}
+
+// hunkRange returns the 1-indexed range of a hunk. An empty range starts at
+// the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
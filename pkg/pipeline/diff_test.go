@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	input := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+
+	other := func(s string) File {
+		return File{Name: "other.txt", Reader: strings.NewReader(s)}
+	}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Diff(other("")), "", ""},
+		{Diff(other(input)), input, ""},
+		{Diff(other("a\n")), "", "--- -\n+++ other.txt\n@@ -0,0 +1 @@\n+a\n"},
+		{Diff(other("")), "a\nb\n", "--- -\n+++ other.txt\n@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{Diff(other("a\nc\n")), "a\nb\n", "--- -\n+++ other.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{Diff(other("x\ny\n")), "a\nb\nc\n", "--- -\n+++ other.txt\n@@ -1,3 +1,2 @@\n-a\n-b\n-c\n+x\n+y\n"},
+		{Diff(other("b\nx\nd\ny\n")), "a\nb\nc\nd\n", "--- -\n+++ other.txt\n@@ -1,4 +1,4 @@\n-a\n b\n-c\n+x\n d\n+y\n"},
+		{
+			Diff(other("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nx\n")),
+			input,
+			"--- -\n+++ other.txt\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+x\n",
+		},
+		{Diff(other("x\ny\nb\n")), "a\nb\n", "--- -\n+++ other.txt\n@@ -1,2 +1,3 @@\n-a\n+x\n+y\n b\n"},
+		{
+			Diff(other("1\n2\nx\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n")),
+			input,
+			"--- -\n+++ other.txt\n" +
+				"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n" +
+				"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			Diff(other("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n")),
+			"0\n" + input,
+			"--- -\n+++ other.txt\n" +
+				"@@ -1,4 +1,3 @@\n-0\n 1\n 2\n 3\n" +
+				"@@ -10,4 +9,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+		{
+			Diff(other("1\n2\n3\n4\nx\n6\n7\n8\ny\n10\n11\n12\n")),
+			input,
+			"--- -\n+++ other.txt\n" +
+				"@@ -2,11 +2,11 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n-9\n+y\n 10\n 11\n 12\n",
+		},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestDiffNames(t *testing.T) {
+	t.Parallel()
+
+	file := func(name string) File {
+		return File{Name: name, Reader: strings.NewReader("a\n")}
+	}
+
+	tests := []struct {
+		input io.Reader
+		want  string
+	}{
+		{strings.NewReader("a\n"), "--- -\n+++ other.txt\n"},
+		{NewFiles(file("in.txt")), "--- in.txt\n+++ other.txt\n"},
+		{NewFiles(file("in.txt"), file("more.txt")), "--- -\n+++ other.txt\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := Diff(File{Name: "other.txt", Reader: strings.NewReader("b\n")})(tc.input, got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v", k, err)
+			}
+
+			if !strings.HasPrefix(got.String(), tc.want) {
+				log.Fatalf("(test %d) wanted prefix: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Extract returns a filter that writes the first match of the compiled regular
+// expression 'regex' in each line. If the regex has capture groups each group
+// is written on its own line instead of the whole match.
+func Extract(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return extract(regex, 1)
+}
+
+// ExtractAll returns a filter that writes every match of the compiled regular
+// expression 'regex' in each line on its own line. If the regex has capture
+// groups each group is written on its own line instead of the whole match.
+func ExtractAll(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return extract(regex, -1)
+}
+
+func extract(regex *regexp.Regexp, n int) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if regex.String() == "" {
+			return nil
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			for _, match := range regex.FindAllStringSubmatch(scanner.Text(), n) {
+				if len(match) == 1 {
+					fmt.Fprintln(w, match[0])
+					continue
+				}
+
+				for _, group := range match[1:] {
+					fmt.Fprintln(w, group)
+				}
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// ExtractJSON returns a filter that writes every match of the compiled regular
+// expression 'regex' in each line as a JSON object of the named capture groups
+// in the order they are defined. Groups that don't participate in the match
+// are empty strings.
+func ExtractJSON(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if err := hasNamedGroups(regex); err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			for _, match := range regex.FindAllStringSubmatch(scanner.Text(), -1) {
+				o := newJSONObject()
+				for i, name := range regex.SubexpNames() {
+					if name != "" {
+						o.set(name, match[i])
+					}
+				}
+
+				fmt.Fprintln(w, encodeJSON(o))
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// ExtractFields returns a filter that writes every match of the compiled
+// regular expression 'regex' in each line as a record of the capture groups.
+// Groups that don't participate in the match are empty fields.
+func ExtractFields(regex *regexp.Regexp) func(io.Reader, chan<- Record) error {
+	return func(r io.Reader, records chan<- Record) error {
+		if regex.NumSubexp() == 0 {
+			return fmt.Errorf("regex must have capture groups, got: %s", regex)
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			for _, match := range regex.FindAllStringSubmatch(scanner.Text(), -1) {
+				records <- match[1:]
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// hasNamedGroups returns an error if 'regex' has no named capture groups.
+func hasNamedGroups(regex *regexp.Regexp) error {
+	for _, name := range regex.SubexpNames() {
+		if name != "" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("regex must have named capture groups, got: %s", regex)
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExtractFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "GET /a 200\nPOST /b 500 /c\nnothing\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Extract(regexp.MustCompile("")), input, ""},
+		{Extract(regexp.MustCompile("/[a-z]")), input, "/a\n/b\n"},
+		{Extract(regexp.MustCompile(`^(\w+) (\S+)`)), input, "GET\n/a\nPOST\n/b\n"},
+		{ExtractAll(regexp.MustCompile("/[a-z]")), input, "/a\n/b\n/c\n"},
+		{ExtractAll(regexp.MustCompile(`/(x)?([a-z])`)), "/a /xb\n", "\na\nx\nb\n"},
+		{ExtractJSON(regexp.MustCompile(`(?P<method>[A-Z]+) (?P<path>\S+) (\d+)`)), input, "{\"method\":\"GET\",\"path\":\"/a\"}\n{\"method\":\"POST\",\"path\":\"/b\"}\n"},
+		{ExtractJSON(regexp.MustCompile(`(?P<k>\w)=(?P<v>\d)?`)), "a=1 b=\n", "{\"k\":\"a\",\"v\":\"1\"}\n{\"k\":\"b\",\"v\":\"\"}\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestExtractFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{ExtractJSON(regexp.MustCompile(`(\w+)`)), "regex must have named capture groups, got: (\\w+)"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("a\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
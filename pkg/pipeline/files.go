@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"io"
+	"os"
+)
+
+// A File is a named input to a pipeline, such as a file given on the command
+// line.
+type File struct {
+	Name string
+	io.Reader
+}
+
+// NewFiles returns an io.Reader that reads from each of the files in turn. A
+// filter reading from it directly (the first filter in a pipeline) can also
+// read each file separately, for example to count each file.
+func NewFiles(fs ...File) *files {
+	readers := []io.Reader{}
+	for _, f := range fs {
+		readers = append(readers, f.Reader)
+	}
+
+	return &files{files: fs, r: io.MultiReader(readers...)}
+}
+
+type files struct {
+	files []File
+	r     io.Reader
+}
+
+func (f *files) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// Names returns the names of the files in the order they're read.
+func (f *files) Names() []string {
+	names := []string{}
+	for _, file := range f.files {
+		names = append(names, file.Name)
+	}
+
+	return names
+}
+
+// inputName returns the name of the file read by 'r' if it was returned by
+// NewFiles() for a single file, or otherwise "-".
+func inputName(r io.Reader) string {
+	if fs, ok := r.(*files); ok && len(fs.files) == 1 {
+		return fs.files[0].Name
+	}
+
+	return "-"
+}
+
+// eachFile calls 'fn' with each file if 'r' was returned by NewFiles() or
+// otherwise with 'r' named "-".
+func eachFile(r io.Reader, fn func(File) error) error {
+	fs, ok := r.(*files)
+	if !ok {
+		return fn(File{Name: "-", Reader: r})
+	}
+
+	for _, f := range fs.files {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// An OutputFile is a named output of a pipeline, such as a file given as a
+// filter argument.
+type OutputFile struct {
+	Name string
+	io.Writer
+}
+
+// Outputs create the files written by a filter that only knows their names
+// once it runs, supplied by the pipeline rather than given as an argument.
+// 'Create' opens the file 'name' to write, truncating it only the first time
+// it's opened. If 'Create' is nil files are created with os.OpenFile.
+type Outputs struct {
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// create returns 'o.Create', or if it's nil a function that truncates each
+// file the first time it's opened and appends to it after.
+func (o Outputs) create() func(name string) (io.WriteCloser, error) {
+	if o.Create != nil {
+		return o.Create
+	}
+
+	created := map[string]bool{}
+
+	return func(name string) (io.WriteCloser, error) {
+		flag := os.O_WRONLY | os.O_APPEND
+		if !created[name] {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+
+		f, err := os.OpenFile(name, flag, 0o666)
+		if err != nil {
+			return nil, err
+		}
+
+		created[name] = true
+
+		return f, nil
+	}
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	files := func() *files {
+		return NewFiles(
+			File{Name: "a.txt", Reader: strings.NewReader("apple\nbanana\n")},
+			File{Name: "b.txt", Reader: strings.NewReader("cherry\n")},
+		)
+	}
+
+	t.Run("concatenated", func(t *testing.T) {
+		t.Parallel()
+
+		got := &bytes.Buffer{}
+
+		p := NewPipeline(files())
+		p.Filter(Join(","))
+
+		if _, err := p.Output(got); err != nil {
+			t.Fatalf("error executing pipeline: %v", err)
+		}
+
+		if want := "apple,banana,cherry\n"; want != got.String() {
+			log.Fatalf("wanted: %q, got: %q", want, got.String())
+		}
+	})
+
+	t.Run("each file", func(t *testing.T) {
+		t.Parallel()
+
+		got := &bytes.Buffer{}
+
+		p := NewPipeline(files())
+		p.Filter(Count())
+
+		if _, err := p.Output(got); err != nil {
+			t.Fatalf("error executing pipeline: %v", err)
+		}
+
+		want := "lines  words  runes  bytes  file\n" +
+			"    2      2     11     13  a.txt\n" +
+			"    1      1      6      7  b.txt\n" +
+			"    3      3     17     20  total\n"
+
+		if want != got.String() {
+			log.Fatalf("wanted: %q, got: %q", want, got.String())
+		}
+	})
+
+	t.Run("prefix each file", func(t *testing.T) {
+		t.Parallel()
+
+		got := &bytes.Buffer{}
+
+		p := NewPipeline(files())
+		p.Filter(Prefix("{file}:{n}: "))
+
+		if _, err := p.Output(got); err != nil {
+			t.Fatalf("error executing pipeline: %v", err)
+		}
+
+		if want := "a.txt:1: apple\na.txt:2: banana\nb.txt:1: cherry\n"; want != got.String() {
+			log.Fatalf("wanted: %q, got: %q", want, got.String())
+		}
+	})
+}
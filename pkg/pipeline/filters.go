@@ -8,10 +8,13 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/dyson/pipesore/pkg/levenshtein"
 )
 
 type filters map[string]filter
@@ -57,8 +60,43 @@ type filter struct {
 	Description string
 }
 
+// Name returns the filters name as written in its definition, for example
+// "!First".
+func (f filter) Name() string {
+	name, _, _ := strings.Cut(f.Definition, "(")
+	return name
+}
+
+// jsonHelp describes the JSON path and invalid policy arguments common to the
+// JSON filters.
+const jsonHelp = "Paths are made up of `.key`, `[\"key\"]` and `[index]` segments, for example `.users[0].name`, and `.` is the whole line. Lines that aren't valid JSON (or where the `path` can't be set or removed) are handled by the `invalid` policy: \"skip\" the line, \"error\" or \"pass\" the line through unchanged."
+
+// aggregateHelp describes the column and invalid policy arguments common to the
+// numeric aggregate filters.
+const aggregateHelp = "The `Column` variants read the number from the 1-indexed `column` defined by splitting with the `delimiter`. Lines that aren't numbers are handled by the `invalid` policy: \"skip\" the line or \"error\"."
+
 var (
 	Filters = filters{
+		"between": {
+			reflect.ValueOf(Between),
+			"Between(start string, end string)",
+			"Returns each block of lines from a line matching the regular expression `start` to the next line matching the regular expression `end`, including the matching lines. A block without an end line continues to the end of the input. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"!between": {
+			reflect.ValueOf(NotBetween),
+			"!Between(start string, end string)",
+			"Returns all lines outside of the blocks returned by `Between()`.",
+		},
+		"betweenexclusive": {
+			reflect.ValueOf(BetweenExclusive),
+			"BetweenExclusive(start string, end string)",
+			"Returns each block of lines between a line matching the regular expression `start` and the next line matching the regular expression `end`, excluding the matching lines. A block without an end line continues to the end of the input. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"!betweenexclusive": {
+			reflect.ValueOf(NotBetweenExclusive),
+			"!BetweenExclusive(start string, end string)",
+			"Returns all lines outside of the blocks returned by `BetweenExclusive()`.",
+		},
 		"columns": {
 			reflect.ValueOf(Columns),
 			"Columns(delimiter string, columns string)",
@@ -69,6 +107,31 @@ var (
 			"ColumnsCSV(delimiter string, columns string)",
 			"Returns the selected `columns` in order where `columns` is a 1-indexed comma separated list of column positions. Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
 		},
+		"columnscsvheader": {
+			reflect.ValueOf(ColumnsCSVHeader),
+			"ColumnsCSVHeader(delimiter string, columns string)",
+			"Returns the header and the selected `columns` in order where `columns` is a comma separated list of column names from the header (the first line). Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
+		},
+		"!columnscsvheader": {
+			reflect.ValueOf(NotColumnsCSVHeader),
+			"!ColumnsCSVHeader(delimiter string, columns string)",
+			"Returns the header and all but the `columns` where `columns` is a comma separated list of column names from the header (the first line). Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
+		},
+		"count": {
+			reflect.ValueOf(Count),
+			"Count()",
+			"Returns a table of the line, word, rune and byte counts. When the first filter and reading multiple files a row is returned for each file followed by a total row.",
+		},
+		"countbytes": {
+			reflect.ValueOf(CountBytes),
+			"CountBytes()",
+			"Returns the byte count.",
+		},
+		"countjson": {
+			reflect.ValueOf(CountJSON),
+			"CountJSON()",
+			"Returns the line, word, rune and byte counts as a JSON object. When the first filter and reading multiple files an object is returned for each file followed by a total object.",
+		},
 		"countlines": {
 			reflect.ValueOf(CountLines),
 			"CountLines()",
@@ -84,6 +147,56 @@ var (
 			"CountWords()",
 			"Returns the word count. Words are delimited by `\\t|\\n|\\v|\\f|\\r|\u00A0|0x85|0xA0`.",
 		},
+		"csv": {
+			reflect.ValueOf(CSV),
+			"CSV(delimiter string)",
+			"Returns each line as a record of fields. Parsing is CSV aware so quoted fields containing the `delimiter` when splitting are preserved.",
+		},
+		"csvtojson": {
+			reflect.ValueOf(CSVToJSON),
+			"CSVToJSON(delimiter string)",
+			"Returns each line after the header (the first line) as a JSON object with the header columns as keys. Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
+		},
+		"diff": {
+			reflect.ValueOf(Diff),
+			"Diff(file string)",
+			"Returns the differences between the input and the lines of `file` in unified format, with 3 lines of context around each change. Nothing is returned if they are the same.",
+		},
+		"dropheadercsv": {
+			reflect.ValueOf(DropHeaderCSV),
+			"DropHeaderCSV(delimiter string)",
+			"Returns all but the header (the first line). Parsing is CSV aware so a header with quoted columns spanning multiple lines is dropped entirely.",
+		},
+		"except": {
+			reflect.ValueOf(Except),
+			"Except(file string)",
+			"Returns the distinct lines that aren't lines of `file`, in the order they were first read.",
+		},
+		"extract": {
+			reflect.ValueOf(Extract),
+			"Extract(regex string)",
+			"Returns the first match of the compiled regular expression 'regex' in each line. If 'regex' has capture groups each group is returned on its own line instead of the whole match. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"extractall": {
+			reflect.ValueOf(ExtractAll),
+			"ExtractAll(regex string)",
+			"Returns every match of the compiled regular expression 'regex' in each line on its own line. If 'regex' has capture groups each group is returned on its own line instead of the whole match. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"extractfields": {
+			reflect.ValueOf(ExtractFields),
+			"ExtractFields(regex string)",
+			"Returns every match of the compiled regular expression 'regex' in each line as a record of the capture groups. Groups that don't participate in the match are empty fields. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"extractjson": {
+			reflect.ValueOf(ExtractJSON),
+			"ExtractJSON(regex string)",
+			"Returns every match of the compiled regular expression 'regex' in each line as a JSON object of the named capture groups, for example `(?P<key>\\w+)=(?P<value>\\w+)`. Groups that don't participate in the match are empty strings. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"fields": {
+			reflect.ValueOf(Fields),
+			"Fields(delimiter string)",
+			"Returns each line as a record of fields defined by splitting with the `delimiter`.",
+		},
 		"first": {
 			reflect.ValueOf(First),
 			"First(n int)",
@@ -94,16 +207,116 @@ var (
 			"!First(n int)",
 			"Returns all but the the first `n` lines where `n` is a positive integer. If the input has less than `n` lines, no lines are returned.",
 		},
+		"fork": {
+			reflect.ValueOf(Fork),
+			"Fork(pipeline, ...)",
+			"Returns the output of each `pipeline` run on the input concurrently, in turn. Pipelines are written without quotes, for example `Fork(CountLines(), Frequency() | First(5))`. The output of the first `pipeline` is returned as it's filtered and the output of the others is written to temporary files until they finish.",
+		},
+		"forklabel": {
+			reflect.ValueOf(ForkLabel),
+			"ForkLabel(pipeline, ...)",
+			"Returns the output of each `pipeline` like `Fork()` with each line prefixed by its `pipeline` and a tab.",
+		},
+		"format": {
+			reflect.ValueOf(Format),
+			"Format(format string, delimiter string)",
+			"Returns all lines rebuilt from `format` with the placeholders `{1}`, `{2}` and so on replaced by the 1-indexed column of the line defined by splitting with the `delimiter`, for example \"{2} -> {1}\". The placeholder `{0}` is the whole line and columns the line doesn't have are empty.",
+		},
 		"frequency": {
 			reflect.ValueOf(Frequency),
 			"Frequency()",
 			"Returns a descending list containing frequency and unique line. Lines with equal frequency are sorted alphabetically.",
 		},
+		"frequencyfield": {
+			reflect.ValueOf(FrequencyField),
+			"FrequencyField(column int)",
+			"Takes records and returns a descending list of records containing frequency and unique field in the 1-indexed `column`. Fields with equal frequency are sorted alphabetically.",
+		},
+		"frequencyfold": {
+			reflect.ValueOf(FrequencyFold),
+			"FrequencyFold()",
+			"Returns a descending list containing frequency and unique line ignoring case. Each line is returned as it was first read. Lines with equal frequency are sorted alphabetically. Case is ignored using Unicode simple case folding.",
+		},
+		"groupby": {
+			reflect.ValueOf(GroupBy),
+			"GroupBy(delimiter string, keys string, aggregates string)",
+			"Returns a line for each unique key of the `keys` columns followed by the `aggregates` of the lines with that key. `keys` is a 1-indexed comma separated list of column positions and `aggregates` is a comma separated list of \"count\" or \"function:column\" where function is one of sum, min, max, mean, median or pN for the Nth percentile, for example \"count,sum:3,p95:4\". Columns are defined by splitting with the `delimiter`. Lines are sorted alphabetically by key.",
+		},
+		"groupbysort": {
+			reflect.ValueOf(GroupBySort),
+			"GroupBySort(delimiter string, keys string, aggregates string, sortBy string)",
+			"Returns the same as `GroupBy()` with lines sorted by the `sortBy` aggregate, which must be one of the `aggregates`, in descending numerical order (largest first). Lines with equal aggregates will be sorted alphabetically by key.",
+		},
+		"highlight": {
+			reflect.ValueOf(Highlight),
+			"Highlight(substring string)",
+			"Returns all lines colouring non-overlapping instances of `substring`. Each highlight filter in a pipeline starts on the next colour, cycling through red, green, yellow, blue, magenta and cyan. Colours aren't written if the NO_COLOR environment variable is set or output isn't a terminal.",
+		},
+		"highlightregex": {
+			reflect.ValueOf(HighlightRegex),
+			"HighlightRegex(regex string)",
+			"Returns all lines colouring matches of the compiled regular expression 'regex'. If 'regex' has capture groups each group is coloured instead of the whole match using the next colour for each group. Each highlight filter in a pipeline starts on the next colour, cycling through red, green, yellow, blue, magenta and cyan. Colours aren't written if the NO_COLOR environment variable is set or output isn't a terminal. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"intersect": {
+			reflect.ValueOf(Intersect),
+			"Intersect(file string)",
+			"Returns the distinct lines that are also lines of `file`, in the order they were first read.",
+		},
 		"join": {
 			reflect.ValueOf(Join),
 			"Join(delimiter string)",
 			"Joins all lines together seperated by `delimiter`.",
 		},
+		"joinfields": {
+			reflect.ValueOf(JoinFields),
+			"JoinFields(delimiter string)",
+			"Takes records and returns each as a line with fields separated by `delimiter`.",
+		},
+		"joinon": {
+			reflect.ValueOf(JoinOn),
+			"JoinOn(file string, leftKey int, rightKey int, delimiter string)",
+			"Returns each line joined with every line of the CSV `file` where the `leftKey` column of the line, defined by splitting with the single rune `delimiter`, equals the `rightKey` column of the `file` line. Joined lines are the columns of the line followed by the columns of the `file` line without its key column. Lines without a match, including lines without the key column, aren't returned. Lines may have different numbers of columns. Columns are 1-indexed. The smaller of the input and the `file` is held in memory while the other is streamed.",
+		},
+		"!joinon": {
+			reflect.ValueOf(NotJoinOn),
+			"!JoinOn(file string, leftKey int, rightKey int, delimiter string)",
+			"Returns the lines where the `leftKey` column of the line doesn't equal the `rightKey` column of any line of the CSV `file`.",
+		},
+		"jsondelete": {
+			reflect.ValueOf(JSONDelete),
+			"JSONDelete(path string, invalid string)",
+			"Returns each line of JSON with the value selected by `path` removed. The whole line (the path `.`) can't be removed. " + jsonHelp,
+		},
+		"jsonget": {
+			reflect.ValueOf(JSONGet),
+			"JSONGet(path string, invalid string)",
+			"Returns the value selected by `path` from each line of JSON. Strings are returned without quotes and all other values as JSON. Lines without the value are skipped. " + jsonHelp,
+		},
+		"jsonset": {
+			reflect.ValueOf(JSONSet),
+			"JSONSet(path string, value string, invalid string)",
+			"Returns each line of JSON with the value selected by `path` set to `value`. If `value` is valid JSON it's set as is, otherwise it's set as a string. Missing object keys are created. " + jsonHelp,
+		},
+		"jsontocolumns": {
+			reflect.ValueOf(JSONToColumns),
+			"JSONToColumns(paths string, delimiter string, invalid string)",
+			"Returns the values selected by `paths`, a comma separated list of paths, from each line of JSON separated by `delimiter`. Strings are returned without quotes and all other values as JSON. Missing values are returned as empty columns. " + jsonHelp,
+		},
+		"jsontocsv": {
+			reflect.ValueOf(JSONToCSV),
+			"JSONToCSV(paths string, delimiter string, invalid string)",
+			"Returns a CSV header of `paths`, a comma separated list of paths, followed by the values selected by `paths` from each line of JSON separated by `delimiter`. Strings are returned without quotes and all other values as JSON. " + jsonHelp,
+		},
+		"jsonwhere": {
+			reflect.ValueOf(JSONWhere),
+			"JSONWhere(path string, value string, invalid string)",
+			"Returns all lines of JSON where the value selected by `path` equals `value`. Strings are compared without quotes and all other values as JSON. " + jsonHelp,
+		},
+		"!jsonwhere": {
+			reflect.ValueOf(NotJSONWhere),
+			"!JSONWhere(path string, value string, invalid string)",
+			"Returns all lines of JSON where the value selected by `path` doesn't equal `value`. Strings are compared without quotes and all other values as JSON. " + jsonHelp,
+		},
 		"last": {
 			reflect.ValueOf(Last),
 			"Last(n int)",
@@ -114,6 +327,26 @@ var (
 			"!Last(n int)",
 			"Returns all but the last `n` lines where `n` is a positive integer. If the input has less than `n` lines, no lines are returned.",
 		},
+		"leftjoinon": {
+			reflect.ValueOf(LeftJoinOn),
+			"LeftJoinOn(file string, leftKey int, rightKey int, delimiter string)",
+			"Returns each line joined like `JoinOn()` but also returns lines without a match followed by empty columns.",
+		},
+		"lines": {
+			reflect.ValueOf(Lines),
+			"Lines(ranges string)",
+			"Returns the lines selected by `ranges` where `ranges` is a comma separated list of 1-indexed line numbers and inclusive ranges of line numbers, for example \"100-200,300\". A range without an end, for example \"300-\", continues to the end of the input.",
+		},
+		"!lines": {
+			reflect.ValueOf(NotLines),
+			"!Lines(ranges string)",
+			"Returns all lines not selected by `ranges`.",
+		},
+		"lower": {
+			reflect.ValueOf(Lower),
+			"Lower()",
+			"Returns all lines in lower case using Unicode case mapping.",
+		},
 		"match": {
 			reflect.ValueOf(Match),
 			"Match(substring string)",
@@ -124,6 +357,41 @@ var (
 			"!Match(substring string)",
 			"Returns all lines that don't contain `substring`.",
 		},
+		"matchcontext": {
+			reflect.ValueOf(MatchContext),
+			"MatchContext(substring string, before int, after int, separator string)",
+			"Returns all lines that contain `substring` along with up to `before` lines before and `after` lines after each match. Overlapping context is merged and groups of lines that aren't adjacent are separated by a `separator` line unless `separator` is empty.",
+		},
+		"matchcsvheader": {
+			reflect.ValueOf(MatchCSVHeader),
+			"MatchCSVHeader(delimiter string, column string, substring string)",
+			"Returns the header (the first line) and all lines where the `column` named in the header contains `substring`. Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
+		},
+		"!matchcsvheader": {
+			reflect.ValueOf(NotMatchCSVHeader),
+			"!MatchCSVHeader(delimiter string, column string, substring string)",
+			"Returns the header (the first line) and all lines where the `column` named in the header doesn't contain `substring`. Parsing is CSV aware so quoted columns containing the `delimiter` when splitting are preserved.",
+		},
+		"matchfield": {
+			reflect.ValueOf(MatchField),
+			"MatchField(column int, substring string)",
+			"Takes records and returns all records where the field in the 1-indexed `column` contains `substring`.",
+		},
+		"!matchfield": {
+			reflect.ValueOf(NotMatchField),
+			"!MatchField(column int, substring string)",
+			"Takes records and returns all records where the field in the 1-indexed `column` doesn't contain `substring`.",
+		},
+		"matchfold": {
+			reflect.ValueOf(MatchFold),
+			"MatchFold(substring string)",
+			"Returns all lines that contain `substring` ignoring case. Case is ignored using Unicode simple case folding.",
+		},
+		"!matchfold": {
+			reflect.ValueOf(NotMatchFold),
+			"!MatchFold(substring string)",
+			"Returns all lines that don't contain `substring` ignoring case. Case is ignored using Unicode simple case folding.",
+		},
 		"matchregex": {
 			reflect.ValueOf(MatchRegex),
 			"MatchRegex(regex string)",
@@ -134,16 +402,276 @@ var (
 			"!MatchRegex(regex string)",
 			"Returns all lines that don't match the compiled regular expression 'regex'. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
 		},
+		"matchregexcontext": {
+			reflect.ValueOf(MatchRegexContext),
+			"MatchRegexContext(regex string, before int, after int, separator string)",
+			"Returns all lines that match the compiled regular expression 'regex' along with up to `before` lines before and `after` lines after each match. Overlapping context is merged and groups of lines that aren't adjacent are separated by a `separator` line unless `separator` is empty. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"matchword": {
+			reflect.ValueOf(MatchWord),
+			"MatchWord(substring string)",
+			"Returns all lines that contain `substring` as a whole word. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"!matchword": {
+			reflect.ValueOf(NotMatchWord),
+			"!MatchWord(substring string)",
+			"Returns all lines that don't contain `substring` as a whole word. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"matchwordfold": {
+			reflect.ValueOf(MatchWordFold),
+			"MatchWordFold(substring string)",
+			"Returns all lines that contain `substring` as a whole word ignoring case. Case is ignored using Unicode simple case folding. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"!matchwordfold": {
+			reflect.ValueOf(NotMatchWordFold),
+			"!MatchWordFold(substring string)",
+			"Returns all lines that don't contain `substring` as a whole word ignoring case. Case is ignored using Unicode simple case folding. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"max": {
+			reflect.ValueOf(Max),
+			"Max(invalid string)",
+			"Returns the maximum of the numbers read, one per line. " + aggregateHelp,
+		},
+		"maxcolumn": {
+			reflect.ValueOf(MaxColumn),
+			"MaxColumn(delimiter string, column int, invalid string)",
+			"Returns the maximum of the numbers in `column`. " + aggregateHelp,
+		},
+		"mean": {
+			reflect.ValueOf(Mean),
+			"Mean(invalid string)",
+			"Returns the mean of the numbers read, one per line. " + aggregateHelp,
+		},
+		"meancolumn": {
+			reflect.ValueOf(MeanColumn),
+			"MeanColumn(delimiter string, column int, invalid string)",
+			"Returns the mean of the numbers in `column`. " + aggregateHelp,
+		},
+		"median": {
+			reflect.ValueOf(Median),
+			"Median(invalid string)",
+			"Returns the estimated median of the numbers read, one per line. Medians are exact for up to five numbers and estimated in constant memory otherwise. " + aggregateHelp,
+		},
+		"mediancolumn": {
+			reflect.ValueOf(MedianColumn),
+			"MedianColumn(delimiter string, column int, invalid string)",
+			"Returns the estimated median of the numbers in `column`. " + aggregateHelp,
+		},
+		"min": {
+			reflect.ValueOf(Min),
+			"Min(invalid string)",
+			"Returns the minimum of the numbers read, one per line. " + aggregateHelp,
+		},
+		"mincolumn": {
+			reflect.ValueOf(MinColumn),
+			"MinColumn(delimiter string, column int, invalid string)",
+			"Returns the minimum of the numbers in `column`. " + aggregateHelp,
+		},
+		"normalize": {
+			reflect.ValueOf(Normalize),
+			"Normalize(form string)",
+			"Returns all lines in the Unicode normalization `form`, one of \"NFC\", \"NFD\", \"NFKC\" or \"NFKD\", so visually identical lines are equal.",
+		},
+		"number": {
+			reflect.ValueOf(Number),
+			"Number(start int, width int, separator string)",
+			"Returns all lines prefixed by their line number, starting at `start`, right aligned to `width` and followed by `separator`.",
+		},
+		"padleft": {
+			reflect.ValueOf(PadLeft),
+			"PadLeft(width int)",
+			"Returns all lines left padded with spaces to `width`. Width is the number of columns needed to display the line in a terminal so wide characters such as CJK and most emoji count as two columns.",
+		},
+		"padright": {
+			reflect.ValueOf(PadRight),
+			"PadRight(width int)",
+			"Returns all lines right padded with spaces to `width`. Width is the number of columns needed to display the line in a terminal so wide characters such as CJK and most emoji count as two columns.",
+		},
+		"percentile": {
+			reflect.ValueOf(Percentile),
+			"Percentile(p int, invalid string)",
+			"Returns the estimated `p`th percentile (0 to 100) of the numbers read, one per line. Percentiles are exact for up to five numbers and estimated in constant memory otherwise. " + aggregateHelp,
+		},
+		"percentilecolumn": {
+			reflect.ValueOf(PercentileColumn),
+			"PercentileColumn(p int, delimiter string, column int, invalid string)",
+			"Returns the estimated `p`th percentile (0 to 100) of the numbers in `column`. " + aggregateHelp,
+		},
+		"prefix": {
+			reflect.ValueOf(Prefix),
+			"Prefix(template string)",
+			"Returns all lines prefixed by `template` with the placeholders `{n}` replaced by the line number and `{file}` by the name of the file the line was read from, for example \"{file}:{n}: \". Files are only known to the first filter, where line numbers restart at 1 for each file. Otherwise the file is \"-\".",
+		},
 		"replace": {
 			reflect.ValueOf(Replace),
 			"Replace(old string, replace string)",
 			"Replaces all non-overlapping instances of `old` with `replace`.",
 		},
+		"replacefield": {
+			reflect.ValueOf(ReplaceField),
+			"ReplaceField(column int, old string, replace string)",
+			"Takes records and replaces all non-overlapping instances of `old` with `replace` in the field in the 1-indexed `column`.",
+		},
+		"replacefold": {
+			reflect.ValueOf(ReplaceFold),
+			"ReplaceFold(old string, replace string)",
+			"Returns all lines replacing non-overlapping instances of `old` ignoring case with `replace`. Case is ignored using Unicode simple case folding.",
+		},
 		"replaceregex": {
 			reflect.ValueOf(ReplaceRegex),
 			"ReplaceRegex(regex string, replace string)",
 			"Replaces all matches of the compiled regular expression `regex` with `replace`. Inside `replace`, `$` signs represent submatches. For example `$1` represents the text of the first submatch. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
 		},
+		"replaceword": {
+			reflect.ValueOf(ReplaceWord),
+			"ReplaceWord(old string, replace string)",
+			"Returns all lines replacing non-overlapping instances of `old` as a whole word with `replace`. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"replacewordfold": {
+			reflect.ValueOf(ReplaceWordFold),
+			"ReplaceWordFold(old string, replace string)",
+			"Returns all lines replacing non-overlapping instances of `old` as a whole word ignoring case with `replace`. Case is ignored using Unicode simple case folding. Words are delimited by anything other than Unicode letters, marks, digits and underscores.",
+		},
+		"sample": {
+			reflect.ValueOf(Sample),
+			"Sample(n int)",
+			"Returns `n` lines chosen at random, in the order they were read. The sample is different each time unless the `--seed` option is given. Only `n` lines are held in memory so the input can be of any size.",
+		},
+		"samplerate": {
+			reflect.ValueOf(SampleRate),
+			"SampleRate(rate float)",
+			"Returns each line with the probability `rate`, between 0 and 1. The sample is different each time unless the `--seed` option is given.",
+		},
+		"selectfields": {
+			reflect.ValueOf(SelectFields),
+			"SelectFields(columns string)",
+			"Takes records and returns records of the selected `columns` in order where `columns` is a 1-indexed comma separated list of field positions.",
+		},
+		"shuffle": {
+			reflect.ValueOf(Shuffle),
+			"Shuffle()",
+			"Returns all lines in a random order. The order is different each time unless the `--seed` option is given.",
+		},
+		"sortfield": {
+			reflect.ValueOf(SortField),
+			"SortField(column int)",
+			"Takes records and returns them sorted alphabetically by the field in the 1-indexed `column`. Records with equal fields keep their order.",
+		},
+		"split": {
+			reflect.ValueOf(Split),
+			"Split(delimiter string)",
+			"Returns each element of all lines defined by splitting with the `delimiter` on its own line. The inverse of `Join()`.",
+		},
+		"splitregex": {
+			reflect.ValueOf(SplitRegex),
+			"SplitRegex(regex string)",
+			"Returns each element of all lines defined by splitting with matches of the compiled regular expression 'regex' on its own line. Regex is in the form of Re2 (https://github.com/google/re2/wiki/Syntax).",
+		},
+		"splitregextrim": {
+			reflect.ValueOf(SplitRegexTrim),
+			"SplitRegexTrim(regex string)",
+			"Returns the same as `SplitRegex()` with leading and trailing white space removed from each element and empty elements dropped.",
+		},
+		"splitto": {
+			reflect.ValueOf(SplitTo),
+			"SplitTo(template string, delimiter string)",
+			"Writes each line to the file named by `template` with the placeholders `{1}`, `{2}` and so on replaced by the 1-indexed column of the line defined by splitting with the `delimiter`, for example \"out/{1}.log\". The placeholder `{0}` is the whole line. Path separators in columns are replaced by \"_\". Files (and their directories) are created, or truncated, when they're first written to and nothing is returned. With `-i` files are appended to for each file after the first. A file can't also be an input. Up to 64 files are kept open at once, so any number of files can be written. A line that gives an empty file name is an error.",
+		},
+		"splittrim": {
+			reflect.ValueOf(SplitTrim),
+			"SplitTrim(delimiter string)",
+			"Returns the same as `Split()` with leading and trailing white space removed from each element and empty elements dropped.",
+		},
+		"stripaccents": {
+			reflect.ValueOf(StripAccents),
+			"StripAccents()",
+			"Returns all lines with accents and other combining marks removed, for example \"café\" becomes \"cafe\".",
+		},
+		"suffix": {
+			reflect.ValueOf(Suffix),
+			"Suffix(template string)",
+			"Returns all lines suffixed by `template` with the same placeholders as `Prefix()`.",
+		},
+		"sum": {
+			reflect.ValueOf(Sum),
+			"Sum(invalid string)",
+			"Returns the sum of the numbers read, one per line. " + aggregateHelp,
+		},
+		"sumcolumn": {
+			reflect.ValueOf(SumColumn),
+			"SumColumn(delimiter string, column int, invalid string)",
+			"Returns the sum of the numbers in `column`. " + aggregateHelp,
+		},
+		"table": {
+			reflect.ValueOf(Table),
+			"Table(delimiter string)",
+			"Returns the columns of each line defined by splitting with the `delimiter` aligned into a table. Columns are padded to the display width of the widest value and separated by two spaces.",
+		},
+		"tee": {
+			reflect.ValueOf(Tee),
+			"Tee(file string)",
+			"Returns all lines unchanged and also writes them to `file`, which is created or truncated once the pipeline runs. With `-i` it's appended to for each file after the first. `file` can't also be an input.",
+		},
+		"template": {
+			reflect.ValueOf(Template),
+			"Template(template string, delimiter string)",
+			"Returns all lines rebuilt by executing the Go text/template `template` (https://pkg.go.dev/text/template) with the line as `.Line`, the line number as `.N` and the 1-indexed columns of the line defined by splitting with the `delimiter` as `.F1`, `.F2` and so on, for example \"{{.F2}} {{upper .F1}}\". Columns the line doesn't have are empty. The functions `upper`, `lower`, `title`, `trim`, `replace old new`, `padLeft width` and `padRight width` are available.",
+		},
+		"title": {
+			reflect.ValueOf(Title),
+			"Title()",
+			"Returns all lines with the first letter of each word in title case and the rest in lower case.",
+		},
+		"tocsv": {
+			reflect.ValueOf(ToCSV),
+			"ToCSV(delimiter string)",
+			"Takes records and returns each as a line of CSV with fields separated by `delimiter`. Fields are quoted as needed.",
+		},
+		"trim": {
+			reflect.ValueOf(Trim),
+			"Trim()",
+			"Returns all lines with leading and trailing white space removed.",
+		},
+		"trimprefix": {
+			reflect.ValueOf(TrimPrefix),
+			"TrimPrefix(prefix string)",
+			"Returns all lines with `prefix` removed from the start of the line.",
+		},
+		"trimsuffix": {
+			reflect.ValueOf(TrimSuffix),
+			"TrimSuffix(suffix string)",
+			"Returns all lines with `suffix` removed from the end of the line.",
+		},
+		"truncate": {
+			reflect.ValueOf(Truncate),
+			"Truncate(width int, tail string)",
+			"Returns all lines cut to `width` with `tail` appended to lines that were cut, for example \"…\". The `tail` counts towards the `width`. Width is the number of columns needed to display the line in a terminal so wide characters such as CJK and most emoji count as two columns.",
+		},
+		"tsvtocsv": {
+			reflect.ValueOf(TSVToCSV),
+			"TSVToCSV()",
+			"Returns each line of tab separated columns as a line of CSV. Columns are quoted as needed.",
+		},
+		"union": {
+			reflect.ValueOf(Union),
+			"Union(file string)",
+			"Returns the distinct lines of the input followed by the distinct lines of `file` that weren't in the input, in the order they were first read.",
+		},
+		"upper": {
+			reflect.ValueOf(Upper),
+			"Upper()",
+			"Returns all lines in upper case using Unicode case mapping, for example \"straße\" becomes \"STRASSE\".",
+		},
+		"words": {
+			reflect.ValueOf(Words),
+			"Words()",
+			"Returns each word of all lines on its own line. Words are delimited by `\\t|\\n|\\v|\\f|\\r|\u00A0|0x85|0xA0`.",
+		},
+		"wrap": {
+			reflect.ValueOf(Wrap),
+			"Wrap(width int)",
+			"Returns all lines wrapped to `width`. Lines are broken at spaces where possible and words wider than `width` are broken between characters. Width is the number of columns needed to display the line in a terminal so wide characters such as CJK and most emoji count as two columns.",
+		},
 	}
 )
 
@@ -152,14 +680,9 @@ var (
 // Columns are defined by splitting with the 'delimiter'.
 func Columns(delimiter string, columns string) func(io.Reader, io.Writer) error {
 	return func(r io.Reader, w io.Writer) error {
-		order := []int{}
-		for _, column := range strings.Split(columns, ",") {
-			index, err := strconv.Atoi(strings.TrimSpace(column))
-			if err != nil {
-				return fmt.Errorf("list of columns must be comma separated list of ints, got: %v", columns)
-			}
-
-			order = append(order, index)
+		order, err := parseColumns(columns)
+		if err != nil {
+			return err
 		}
 
 		scanner := bufio.NewScanner(r)
@@ -190,33 +713,12 @@ func ColumnsCSV(delimiter string, columns string) func(io.Reader, io.Writer) err
 			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
 		}
 
-		order := []int{}
-		for _, column := range strings.Split(columns, ",") {
-			index, err := strconv.Atoi(strings.TrimSpace(column))
-			if err != nil {
-				return fmt.Errorf("list of columns must be comma separated list of ints, got: %v", columns)
-			}
-
-			order = append(order, index)
-		}
-
-		reader := csv.NewReader(r)
-		reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
-		// We really shouldn't be tolerant of malformed CSV input (and should
-		// error) however we can set LazyQuotes to be less strict for commonly
-		// incorrect quoting.
-		//
-		// Unfortunately how incorrect quoting should be interpreted is highly
-		// dependent on how it was incorrectly implemented and so with LazyQuotes
-		// enabled we will in some cases silently parse malformed CSV in a possibly
-		// unexpected way to the user.
-		//
-		// On the other hand users don't always have control over the generation of
-		// the CSV input and so it is hoped that the trade-off in using LazyQuotes
-		// will allow for a better experience overall. If this is not that case we
-		// can disable LazyQuotes and only parse valid rfc4180
-		// (https://www.rfc-editor.org/rfc/rfc4180.html) csv.
-		reader.LazyQuotes = true
+		order, err := parseColumns(columns)
+		if err != nil {
+			return err
+		}
+
+		reader := newCSVReader(r, delimiter)
 
 		writer := csv.NewWriter(w)
 		defer writer.Flush()
@@ -244,6 +746,383 @@ func ColumnsCSV(delimiter string, columns string) func(io.Reader, io.Writer) err
 	}
 }
 
+// ColumnsCSVHeader returns a CSV aware filter that reads the first line as a
+// header and writes the header and lines of the selected 'columns' in the
+// order provided where 'columns' is a comma separated list of column names.
+func ColumnsCSVHeader(delimiter string, columns string) func(io.Reader, io.Writer) error {
+	return columnsCSVHeader(delimiter, columns, false)
+}
+
+// NotColumnsCSVHeader returns a CSV aware filter that reads the first line as
+// a header and writes the header and lines of all columns except 'columns'
+// where 'columns' is a comma separated list of column names.
+func NotColumnsCSVHeader(delimiter string, columns string) func(io.Reader, io.Writer) error {
+	return columnsCSVHeader(delimiter, columns, true)
+}
+
+func columnsCSVHeader(delimiter string, columns string, not bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		reader := newCSVReader(r, delimiter)
+
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		order, err := headerColumns(header, columns)
+		if err != nil {
+			return err
+		}
+
+		if not {
+			excluded := map[int]bool{}
+			for _, v := range order {
+				excluded[v] = true
+			}
+
+			order = []int{}
+			for i := range header {
+				if !excluded[i] {
+					order = append(order, i)
+				}
+			}
+		}
+
+		writer := csv.NewWriter(w)
+		writer.Comma = reader.Comma
+		defer writer.Flush()
+
+		for lineColumns := header; ; {
+			output := []string{}
+			for _, v := range order {
+				if v < len(lineColumns) {
+					output = append(output, lineColumns[v])
+				}
+			}
+
+			writer.Write(output)
+
+			lineColumns, err = reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// MatchCSVHeader returns a CSV aware filter that reads the first line as a
+// header and writes the header and lines where the named 'column' contains
+// 'substring'.
+func MatchCSVHeader(delimiter string, column string, substring string) func(io.Reader, io.Writer) error {
+	return matchCSVHeader(delimiter, column, substring, false)
+}
+
+// NotMatchCSVHeader returns a CSV aware filter that reads the first line as a
+// header and writes the header and lines where the named 'column' doesn't
+// contain 'substring'.
+func NotMatchCSVHeader(delimiter string, column string, substring string) func(io.Reader, io.Writer) error {
+	return matchCSVHeader(delimiter, column, substring, true)
+}
+
+func matchCSVHeader(delimiter string, column string, substring string, not bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		reader := newCSVReader(r, delimiter)
+
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		order, err := headerColumns(header, column)
+		if err != nil {
+			return err
+		}
+		index := order[0]
+
+		writer := csv.NewWriter(w)
+		writer.Comma = reader.Comma
+		defer writer.Flush()
+
+		writer.Write(header)
+
+		for {
+			lineColumns, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			field := ""
+			if index < len(lineColumns) {
+				field = lineColumns[index]
+			}
+
+			if strings.Contains(field, substring) != not {
+				writer.Write(lineColumns)
+			}
+		}
+
+		return nil
+	}
+}
+
+// DropHeaderCSV returns a CSV aware filter that writes all lines except the
+// first (the header). Unlike !First(1) the header may contain quoted columns
+// spanning multiple lines.
+func DropHeaderCSV(delimiter string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		reader := newCSVReader(r, delimiter)
+		reader.FieldsPerRecord = -1
+
+		writer := csv.NewWriter(w)
+		writer.Comma = reader.Comma
+		defer writer.Flush()
+
+		for i := 0; ; i++ {
+			lineColumns, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if i > 0 {
+				writer.Write(lineColumns)
+			}
+		}
+
+		return nil
+	}
+}
+
+// headerColumns returns the 0-indexed positions in the 'header' of 'columns',
+// a comma separated list of column names.
+func headerColumns(header []string, columns string) ([]int, error) {
+	names := []string{}
+	for _, name := range header {
+		names = append(names, strings.TrimSpace(name))
+	}
+
+	order := []int{}
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+
+		index := slices.Index(names, column)
+		if index == -1 {
+			if suggestion := levenshtein.Closest(column, names); suggestion != "" {
+				return nil, fmt.Errorf("unknown column '%s' in CSV header, did you mean '%s'?", column, suggestion)
+			}
+
+			return nil, fmt.Errorf("unknown column '%s' in CSV header", column)
+		}
+
+		order = append(order, index)
+	}
+
+	return order, nil
+}
+
+// parseColumns returns the column positions from 'columns', a comma separated
+// list of ints.
+func parseColumns(columns string) ([]int, error) {
+	order := []int{}
+	for _, column := range strings.Split(columns, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(column))
+		if err != nil || index < 1 {
+			return nil, fmt.Errorf("list of columns must be comma separated list of positive ints, got: %v", columns)
+		}
+
+		order = append(order, index)
+	}
+
+	return order, nil
+}
+
+// newCSVReader returns a csv.Reader splitting fields with 'delimiter', which
+// must be a single rune.
+func newCSVReader(r io.Reader, delimiter string) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	// We really shouldn't be tolerant of malformed CSV input (and should
+	// error) however we can set LazyQuotes to be less strict for commonly
+	// incorrect quoting.
+	//
+	// Unfortunately how incorrect quoting should be interpreted is highly
+	// dependent on how it was incorrectly implemented and so with LazyQuotes
+	// enabled we will in some cases silently parse malformed CSV in a possibly
+	// unexpected way to the user.
+	//
+	// On the other hand users don't always have control over the generation of
+	// the CSV input and so it is hoped that the trade-off in using LazyQuotes
+	// will allow for a better experience overall. If this is not that case we
+	// can disable LazyQuotes and only parse valid rfc4180
+	// (https://www.rfc-editor.org/rfc/rfc4180.html) csv.
+	reader.LazyQuotes = true
+
+	return reader
+}
+
+// Count returns a filter that writes a table of the number of lines, words
+// (as defined by strings.Fields()), runes and bytes read. If the filter reads
+// multiple files (as the first filter) a row is written for each file followed
+// by a total row.
+func Count() func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		counts, err := countFiles(r)
+		if err != nil {
+			return err
+		}
+
+		table := [][]string{{"lines", "words", "runes", "bytes", "file"}}
+		for _, c := range counts {
+			table = append(table, []string{
+				strconv.Itoa(c.lines),
+				strconv.Itoa(c.words),
+				strconv.Itoa(c.runes),
+				strconv.FormatInt(c.bytes, 10),
+				c.name,
+			})
+		}
+
+		widths := make([]int, len(table[0]))
+		for _, row := range table {
+			for i, column := range row {
+				widths[i] = max(widths[i], len(column))
+			}
+		}
+
+		for _, row := range table {
+			for i, column := range row[:len(row)-1] {
+				fmt.Fprint(w, padLeft(column, widths[i]), "  ")
+			}
+
+			fmt.Fprintln(w, row[len(row)-1])
+		}
+
+		return nil
+	}
+}
+
+// CountJSON returns a filter that writes the number of lines, words (as
+// defined by strings.Fields()), runes and bytes read as a JSON object. If the
+// filter reads multiple files (as the first filter) an object is written for
+// each file followed by a total object.
+func CountJSON() func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		counts, err := countFiles(r)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range counts {
+			o := newJSONObject()
+			o.set("file", c.name)
+			o.set("lines", c.lines)
+			o.set("words", c.words)
+			o.set("runes", c.runes)
+			o.set("bytes", c.bytes)
+
+			fmt.Fprintln(w, encodeJSON(o))
+		}
+
+		return nil
+	}
+}
+
+type count struct {
+	name  string
+	lines int
+	words int
+	runes int
+	bytes int64
+}
+
+// countFiles counts each file read in a single pass. A total is added if there
+// are multiple files.
+func countFiles(r io.Reader) ([]count, error) {
+	counts := []count{}
+	total := count{name: "total"}
+
+	err := eachFile(r, func(f File) error {
+		c := count{name: f.Name}
+
+		cr := &countingReader{r: f}
+		scanner := bufio.NewScanner(cr)
+
+		for scanner.Scan() {
+			c.lines++
+			c.words += len(strings.Fields(scanner.Text()))
+			c.runes += utf8.RuneCountInString(scanner.Text())
+		}
+
+		c.bytes = cr.n
+		counts = append(counts, c)
+
+		total.lines += c.lines
+		total.words += c.words
+		total.runes += c.runes
+		total.bytes += c.bytes
+
+		return scanner.Err()
+	})
+
+	if len(counts) > 1 {
+		counts = append(counts, total)
+	}
+
+	return counts, err
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+
+	return n, err
+}
+
+// CountBytes returns a filter that writes the number of bytes read.
+func CountBytes() func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		n, err := io.Copy(io.Discard, r)
+
+		fmt.Fprintln(w, n)
+
+		return err
+	}
+}
+
 // CountLines returns a filter that writes the number of lines read.
 func CountLines() func(io.Reader, io.Writer) error {
 	return func(r io.Reader, w io.Writer) error {
@@ -357,6 +1236,66 @@ func Join(delimiter string) func(io.Reader, io.Writer) error {
 	}
 }
 
+// Split returns a filter that writes each element of all lines defined by
+// splitting with the 'delimiter' on its own line.
+func Split(delimiter string) func(io.Reader, io.Writer) error {
+	return split(func(line string) []string {
+		return strings.Split(line, delimiter)
+	}, false)
+}
+
+// SplitTrim returns a filter like Split with leading and trailing white space
+// removed from each element and empty elements dropped.
+func SplitTrim(delimiter string) func(io.Reader, io.Writer) error {
+	return split(func(line string) []string {
+		return strings.Split(line, delimiter)
+	}, true)
+}
+
+// SplitRegex returns a filter that writes each element of all lines defined by
+// splitting with matches of the compiled regular expression 'regex' on its own
+// line.
+func SplitRegex(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return split(func(line string) []string {
+		return regex.Split(line, -1)
+	}, false)
+}
+
+// SplitRegexTrim returns a filter like SplitRegex with leading and trailing
+// white space removed from each element and empty elements dropped.
+func SplitRegexTrim(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return split(func(line string) []string {
+		return regex.Split(line, -1)
+	}, true)
+}
+
+// Words returns a filter that writes each word of all lines on its own line.
+// Words are delimited by the same white space as CountWords.
+func Words() func(io.Reader, io.Writer) error {
+	return split(strings.Fields, false)
+}
+
+func split(fn func(line string) []string, trim bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			for _, element := range fn(scanner.Text()) {
+				if trim {
+					element = strings.TrimSpace(element)
+					if element == "" {
+						continue
+					}
+				}
+
+				fmt.Fprintln(w, element)
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
 // Last returns a filter that writes the last 'n' lines.
 func Last(n int) func(io.Reader, io.Writer) error {
 	return func(r io.Reader, w io.Writer) error {
@@ -470,6 +1409,93 @@ func MatchRegex(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
 	}
 }
 
+// MatchContext returns a filter that writes lines containing 'substring' along
+// with up to 'before' lines before and 'after' lines after each match.
+// Overlapping context is merged and non-adjacent groups of lines are separated
+// by a 'separator' line unless 'separator' is empty.
+func MatchContext(substring string, before, after int, separator string) func(io.Reader, io.Writer) error {
+	return matchContext(func(line string) bool {
+		return substring != "" && strings.Contains(line, substring)
+	}, before, after, separator)
+}
+
+// MatchRegexContext returns a filter that writes lines matching the compiled
+// regular expression 'regex' along with up to 'before' lines before and 'after'
+// lines after each match. Overlapping context is merged and non-adjacent
+// groups of lines are separated by a 'separator' line unless 'separator' is
+// empty.
+func MatchRegexContext(regex *regexp.Regexp, before, after int, separator string) func(io.Reader, io.Writer) error {
+	return matchContext(func(line string) bool {
+		return regex.String() != "" && regex.MatchString(line)
+	}, before, after, separator)
+}
+
+func matchContext(match func(string) bool, before, after int, separator string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if before < 0 || after < 0 {
+			return fmt.Errorf("context lines must be 0 or more, got: %d, %d", before, after)
+		}
+
+		type line struct {
+			n    int
+			text string
+		}
+
+		// the lines before the current line that haven't been written
+		var context *ring.Ring
+		if before > 0 {
+			context = ring.New(before)
+		}
+
+		// the number of the last line written and how many more lines after
+		// a match to write
+		written, remaining := 0, 0
+
+		write := func(l line) {
+			if written > 0 && l.n > written+1 && separator != "" {
+				fmt.Fprintln(w, separator)
+			}
+
+			fmt.Fprintln(w, l.text)
+			written = l.n
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			switch {
+			case match(scanner.Text()):
+				if context != nil {
+					context.Do(func(p any) {
+						if p != nil {
+							write(p.(line))
+						}
+					})
+
+					for i := 0; i < before; i++ {
+						context.Value = nil
+						context = context.Next()
+					}
+				}
+
+				write(line{n, scanner.Text()})
+				remaining = after
+			case remaining > 0:
+				write(line{n, scanner.Text()})
+				remaining--
+			case context != nil:
+				context.Value = line{n, scanner.Text()}
+				context = context.Next()
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
 // NotMatchRegex returns a filter that writes lines not matching the compiled
 // regular expression 'regex'.
 func NotMatchRegex(regex *regexp.Regexp) func(io.Reader, io.Writer) error {
@@ -599,7 +1625,7 @@ func Frequency() func(io.Reader, io.Writer) error {
 		fieldWidth := len(strconv.Itoa(maxCount))
 
 		for _, item := range freqs {
-			fmt.Fprintf(w, "%*d %s\n", fieldWidth, item.count, item.line)
+			fmt.Fprintf(w, "%s %s\n", padLeft(strconv.Itoa(item.count), fieldWidth), item.line)
 		}
 
 		return nil
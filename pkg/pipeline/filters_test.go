@@ -26,6 +26,18 @@ func TestFilters(t *testing.T) {
 		{ColumnsCSV(",", "3,2,1"), "one\t\tthree\n", "one\t\tthree\n"},
 		{ColumnsCSV("\t", "9"), "one\t\tthree\n", "\n"},
 		{ColumnsCSV(",", "3,2,1"), "one,\"t,w,o\",\"th\"\"ree\"\n", "\"th\"\"ree\",\"t,w,o\",one\n"},
+		{ColumnsCSVHeader(",", "email, id"), "id,name,email\n1,a,a@x\n2,b,b@x\n", "email,id\na@x,1\nb@x,2\n"},
+		{ColumnsCSVHeader("\t", "name"), "id\tname\n1\t\"a\tb\"\n", "name\n\"a\tb\"\n"},
+		{ColumnsCSVHeader(",", "id"), "", ""},
+		{NotColumnsCSVHeader(",", "name"), "id,name,email\n1,a,a@x\n", "id,email\n1,a@x\n"},
+		{MatchCSVHeader(",", "name", "b"), "id,name\n1,a\n2,b\n3,bb\n", "id,name\n2,b\n3,bb\n"},
+		{NotMatchCSVHeader(",", "name", "b"), "id,name\n1,a\n2,b\n3,bb\n", "id,name\n1,a\n"},
+		{DropHeaderCSV(","), "\"i\nd\",name\n1,a\n2,b\n", "1,a\n2,b\n"},
+		{Count(), "", "lines  words  runes  bytes  file\n    0      0      0      0  -\n"},
+		{Count(), input + " 🍎", "lines  words  runes  bytes  file\n    4      4     19     25  -\n"},
+		{CountBytes(), "", "0\n"},
+		{CountBytes(), input + " 🍎", "25\n"},
+		{CountJSON(), input, "{\"file\":\"-\",\"lines\":3,\"words\":3,\"runes\":17,\"bytes\":20}\n"},
 		{CountLines(), "", "0\n"},
 		{CountLines(), input, "3\n"},
 		{CountRunes(), "", "0\n"},
@@ -39,7 +51,14 @@ func TestFilters(t *testing.T) {
 		{NotFirst(2), input, "cherry\n"},
 		{NotFirst(10), input, ""},
 		{Frequency(), input + "apple\n", "2 apple\n1 banana\n1 cherry\n"},
+		{Frequency(), strings.Repeat("apple\n", 10) + "banana\n", "10 apple\n 1 banana\n"},
 		{Join(", "), input, "apple, banana, cherry\n"},
+		{Split(", "), "apple, banana, cherry\n", input},
+		{Split(","), "a,,b\n\n", "a\n\nb\n\n"},
+		{SplitTrim(","), " a , ,b\n\n", "a\nb\n"},
+		{SplitRegex(regexp.MustCompile("[,;] *")), "a, b;c\n", "a\nb\nc\n"},
+		{SplitRegexTrim(regexp.MustCompile("[,;]")), "a, b;;c \n", "a\nb\nc\n"},
+		{Words(), " cat  cat\tdog\u00a0bird \n\n", "cat\ncat\ndog\nbird\n"},
 		{Last(-1), input, ""},
 		{Last(2), input, "banana\ncherry\n"},
 		{Last(10), input, input},
@@ -55,6 +74,16 @@ func TestFilters(t *testing.T) {
 		{MatchRegex(regexp.MustCompile("")), input, ""},
 		{MatchRegex(regexp.MustCompile("a.+e")), input, "apple\n"},
 		{MatchRegex(regexp.MustCompile("[0-9]")), input, ""},
+		{MatchContext("", 1, 1, "--"), input, ""},
+		{MatchContext("banana", 0, 0, "--"), input, "banana\n"},
+		{MatchContext("banana", 1, 1, "--"), input, input},
+		{MatchContext("e", 5, 0, "--"), input, input},
+		{MatchContext("x", 1, 1, "--"), "1\n2\nx\n4\n5\n6\nx\n8\nx\n10\n11\n", "2\nx\n4\n--\n6\nx\n8\nx\n10\n"},
+		{MatchContext("x", 0, 0, "--"), "x\n2\nx\nx\n", "x\n--\nx\nx\n"},
+		{MatchContext("x", 0, 0, ""), "x\n2\nx\n", "x\nx\n"},
+		{MatchRegexContext(regexp.MustCompile(""), 1, 1, "--"), input, ""},
+		{MatchRegexContext(regexp.MustCompile("^c"), 1, 0, "..."), input, "banana\ncherry\n"},
+		{MatchRegexContext(regexp.MustCompile("^a|^c"), 0, 0, "..."), input, "apple\n...\ncherry\n"},
 		{NotMatchRegex(regexp.MustCompile("")), input, ""},
 		{NotMatchRegex(regexp.MustCompile("a.+e")), input, "banana\ncherry\n"},
 		{NotMatchRegex(regexp.MustCompile("[0-9]")), input, input},
@@ -87,3 +116,34 @@ func TestFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{ColumnsCSVHeader(",", "id,emial"), "id,email\n", "unknown column 'emial' in CSV header, did you mean 'email'?"},
+		{MatchCSVHeader(",", "zzz", ""), "id,email\n", "unknown column 'zzz' in CSV header"},
+		{MatchContext("a", -1, 0, "--"), "a\n", "context lines must be 0 or more, got: -1, 0"},
+		{MatchRegexContext(regexp.MustCompile("a"), 0, -2, "--"), "a\n", "context lines must be 0 or more, got: 0, -2"},
+		{Columns(",", "0"), "a\n", "list of columns must be comma separated list of positive ints, got: 0"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader(tc.input), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
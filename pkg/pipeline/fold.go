@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A literalMatcher finds 'substring' in lines, optionally ignoring case using
+// Unicode simple case folding and optionally only as a whole word, that is not
+// preceded or followed by a Unicode letter, mark, digit or underscore.
+type literalMatcher struct {
+	regex *regexp.Regexp
+	word  bool
+}
+
+func newLiteralMatcher(substring string, fold, word bool) literalMatcher {
+	pattern := regexp.QuoteMeta(substring)
+	if fold {
+		pattern = "(?i)" + pattern
+	}
+
+	return literalMatcher{regexp.MustCompile(pattern), word}
+}
+
+// find returns the start and end of the first match in 's' or -1, -1 if
+// there is no match.
+func (m literalMatcher) find(s string) (int, int) {
+	for i := 0; i <= len(s); {
+		loc := m.regex.FindStringIndex(s[i:])
+		if loc == nil {
+			return -1, -1
+		}
+
+		start, end := i+loc[0], i+loc[1]
+		if !m.word || isWholeWord(s, start, end) {
+			return start, end
+		}
+
+		// the match isn't a whole word so retry from the next rune in case a
+		// later overlapping match is
+		_, size := utf8.DecodeRuneInString(s[start:])
+		i = start + max(size, 1)
+	}
+
+	return -1, -1
+}
+
+func (m literalMatcher) match(s string) bool {
+	start, _ := m.find(s)
+	return start != -1
+}
+
+// replaceAll returns 's' with non-overlapping matches replaced by 'replace'.
+func (m literalMatcher) replaceAll(s, replace string) string {
+	var b strings.Builder
+
+	for {
+		start, end := m.find(s)
+		if start == -1 || start == end {
+			break
+		}
+
+		b.WriteString(s[:start])
+		b.WriteString(replace)
+		s = s[end:]
+	}
+
+	b.WriteString(s)
+
+	return b.String()
+}
+
+// isWholeWord returns true if 's' from 'start' to 'end' isn't preceded or
+// followed by a word rune.
+func isWholeWord(s string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(s[:start])
+	after, _ := utf8.DecodeRuneInString(s[end:])
+
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// foldKey returns 's' with each rune replaced by the smallest rune it is equal
+// to under Unicode simple case folding so strings equal ignoring case have
+// equal keys.
+func foldKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		smallest := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			smallest = min(smallest, f)
+		}
+
+		return smallest
+	}, s)
+}
+
+func matchLiteral(substring string, fold, word, not bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if substring == "" {
+			return nil
+		}
+
+		matcher := newLiteralMatcher(substring, fold, word)
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			if matcher.match(scanner.Text()) != not {
+				fmt.Fprintln(w, scanner.Text())
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+func replaceLiteral(old, replace string, fold, word bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		matcher := newLiteralMatcher(old, fold, word)
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			if old == "" {
+				fmt.Fprintln(w, scanner.Text())
+				continue
+			}
+
+			fmt.Fprintln(w, matcher.replaceAll(scanner.Text(), replace))
+		}
+
+		return scanner.Err()
+	}
+}
+
+// MatchFold returns a filter that writes lines containing 'substring' ignoring
+// case.
+func MatchFold(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, true, false, false)
+}
+
+// NotMatchFold returns a filter that writes lines not containing 'substring'
+// ignoring case.
+func NotMatchFold(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, true, false, true)
+}
+
+// MatchWord returns a filter that writes lines containing 'substring' as a
+// whole word.
+func MatchWord(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, false, true, false)
+}
+
+// NotMatchWord returns a filter that writes lines not containing 'substring' as
+// a whole word.
+func NotMatchWord(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, false, true, true)
+}
+
+// MatchWordFold returns a filter that writes lines containing 'substring' as a
+// whole word ignoring case.
+func MatchWordFold(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, true, true, false)
+}
+
+// NotMatchWordFold returns a filter that writes lines not containing
+// 'substring' as a whole word ignoring case.
+func NotMatchWordFold(substring string) func(io.Reader, io.Writer) error {
+	return matchLiteral(substring, true, true, true)
+}
+
+// ReplaceFold returns a filter that writes all lines replacing non-overlapping
+// instances of 'old' ignoring case with 'replace'.
+func ReplaceFold(old, replace string) func(io.Reader, io.Writer) error {
+	return replaceLiteral(old, replace, true, false)
+}
+
+// ReplaceWord returns a filter that writes all lines replacing non-overlapping
+// instances of 'old' as a whole word with 'replace'.
+func ReplaceWord(old, replace string) func(io.Reader, io.Writer) error {
+	return replaceLiteral(old, replace, false, true)
+}
+
+// ReplaceWordFold returns a filter that writes all lines replacing
+// non-overlapping instances of 'old' as a whole word ignoring case with
+// 'replace'.
+func ReplaceWordFold(old, replace string) func(io.Reader, io.Writer) error {
+	return replaceLiteral(old, replace, true, true)
+}
+
+// FrequencyFold returns a filter that writes unique lines ignoring case,
+// prefixed by a frequency count, in descending numerical order (most frequent
+// lines first). Each line is written as it was first read. Lines with equal
+// frequency will be sorted alphabetically.
+func FrequencyFold() func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		type frequency struct {
+			line  string
+			count int
+		}
+
+		freq := map[string]*frequency{}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			key := foldKey(scanner.Text())
+			if _, ok := freq[key]; !ok {
+				freq[key] = &frequency{line: scanner.Text()}
+			}
+
+			freq[key].count++
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		freqs := make([]*frequency, 0, len(freq))
+
+		var maxCount int
+
+		for _, item := range freq {
+			freqs = append(freqs, item)
+			maxCount = max(maxCount, item.count)
+		}
+
+		sort.Slice(freqs, func(i, j int) bool {
+			x, y := freqs[i].count, freqs[j].count
+
+			if x == y {
+				return freqs[i].line < freqs[j].line
+			}
+
+			return x > y
+		})
+
+		fieldWidth := len(strconv.Itoa(maxCount))
+
+		for _, item := range freqs {
+			fmt.Fprintf(w, "%s %s\n", padLeft(strconv.Itoa(item.count), fieldWidth), item.line)
+		}
+
+		return nil
+	}
+}
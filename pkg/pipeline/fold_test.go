@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestFoldFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "Error: disk\nerror: net\nterror\nERRORS\nok\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{MatchFold(""), input, ""},
+		{MatchFold("error"), input, "Error: disk\nerror: net\nterror\nERRORS\n"},
+		{MatchFold("straße"), "STRAßE\nstrasse\n", "STRAßE\n"},
+		{MatchFold("kelvin k"), "KELVIN K\n", "KELVIN K\n"},
+		{MatchFold("σ"), "ΟΔΟΣ\nοδος\nok\n", "ΟΔΟΣ\nοδος\n"},
+		{NotMatchFold("error"), input, "ok\n"},
+		{MatchWord("error"), input, "error: net\n"},
+		{MatchWord("aa"), "aaa aa\n", "aaa aa\n"},
+		{MatchWord("café"), "cafés\nun café.\n", "un café.\n"},
+		{MatchWord("e.g."), "e.g. this\n", "e.g. this\n"},
+		{NotMatchWord("error"), input, "Error: disk\nterror\nERRORS\nok\n"},
+		{MatchWordFold("error"), input, "Error: disk\nerror: net\n"},
+		{NotMatchWordFold("error"), input, "terror\nERRORS\nok\n"},
+		{ReplaceFold("error", "warn"), input, "warn: disk\nwarn: net\ntwarn\nwarnS\nok\n"},
+		{ReplaceFold("", "x"), "ab\n", "ab\n"},
+		{ReplaceWord("error", "warn"), "error terror error_ error\n", "warn terror error_ warn\n"},
+		{ReplaceWordFold("error", "warn"), input, "warn: disk\nwarn: net\nterror\nERRORS\nok\n"},
+		{FrequencyFold(), "b\nStraße\nSTRASSE\nstraße\nB\na\n", "2 Straße\n2 b\n1 STRASSE\n1 a\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// A Branch is a pipeline given as a filter argument, such as to Fork. Name is
+// the pipeline as written.
+type Branch struct {
+	Name   string
+	Filter func(io.Reader, io.Writer) error
+}
+
+// Compose returns a filter that runs 'filters' as a pipeline. Each filter must
+// be one of the four filter function types and the pipeline must take and
+// return lines.
+func Compose(filters ...any) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		p := NewPipeline(r)
+
+		for _, filter := range filters {
+			switch filter := filter.(type) {
+			case func(io.Reader, io.Writer) error:
+				p.Filter(filter)
+			case func(io.Reader, chan<- Record) error:
+				p.ToRecords(filter)
+			case func(<-chan Record, chan<- Record) error:
+				p.FilterRecords(filter)
+			case func(<-chan Record, io.Writer) error:
+				p.FromRecords(filter)
+			}
+		}
+
+		_, err := p.Output(w)
+
+		return err
+	}
+}
+
+// Fork returns a filter that runs each of the 'branches' on the input
+// concurrently and writes their output in turn. The output of the first
+// branch is written as it's filtered and the output of the others is written
+// to temporary files until they finish. The input is only read as fast as the
+// slowest branch reads it.
+func Fork(branches ...Branch) func(io.Reader, io.Writer) error {
+	return fork(branches, false)
+}
+
+// ForkLabel returns a filter that runs each of the 'branches' like Fork but
+// writes each line prefixed by the name of its branch and a tab.
+func ForkLabel(branches ...Branch) func(io.Reader, io.Writer) error {
+	return fork(branches, true)
+}
+
+// errBranchesDone stops reading the input when no branch is reading it.
+var errBranchesDone = errors.New("all branches are done")
+
+// fanOut writes to each pipe until its reader is closed.
+type fanOut struct {
+	writers []*io.PipeWriter
+	done    []bool
+}
+
+func (f *fanOut) Write(p []byte) (int, error) {
+	reading := false
+
+	for i, w := range f.writers {
+		if f.done[i] {
+			continue
+		}
+
+		if _, err := w.Write(p); err != nil {
+			f.done[i] = true
+			continue
+		}
+
+		reading = true
+	}
+
+	if !reading {
+		return 0, errBranchesDone
+	}
+
+	return len(p), nil
+}
+
+func fork(branches []Branch, label bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		f := &fanOut{
+			writers: make([]*io.PipeWriter, len(branches)),
+			done:    make([]bool, len(branches)),
+		}
+
+		outputs := make([]*os.File, len(branches))
+		errs := make([]error, len(branches))
+
+		defer func() {
+			for _, output := range outputs {
+				if output != nil {
+					output.Close()
+					os.Remove(output.Name())
+				}
+			}
+		}()
+
+		for i := 1; i < len(branches); i++ {
+			output, err := os.CreateTemp("", "pipesore-fork-*")
+			if err != nil {
+				return err
+			}
+
+			outputs[i] = output
+		}
+
+		var wg sync.WaitGroup
+
+		for i, branch := range branches {
+			pr, pw := io.Pipe()
+			f.writers[i] = pw
+
+			var out io.Writer = w
+			if i > 0 {
+				out = outputs[i]
+			}
+
+			filter := branch.Filter
+			if label {
+				name := branch.Name
+				filter = Compose(filter, mapLines(func(line string) string {
+					return name + "\t" + line
+				}))
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				errs[i] = filter(pr, out)
+
+				// the branch may not have read all of its input
+				pr.Close()
+			}(i)
+		}
+
+		_, err := io.Copy(f, r)
+
+		for _, pw := range f.writers {
+			pw.Close()
+		}
+
+		wg.Wait()
+
+		if err != nil && err != errBranchesDone {
+			return err
+		}
+
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, output := range outputs {
+			if output == nil {
+				continue
+			}
+
+			if _, err := output.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(w, output); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Tee returns a filter that writes all lines unchanged and also writes them to
+// the 'file'.
+func Tee(file OutputFile) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		// a file that's only created once written is created even if there
+		// are no lines
+		if _, err := file.Write(nil); err != nil {
+			return err
+		}
+
+		_, err := io.Copy(io.MultiWriter(w, file), r)
+
+		return err
+	}
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestForkFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "apple\nbanana\ncherry\n"
+
+	branch := func(name string, filters ...any) Branch {
+		return Branch{Name: name, Filter: Compose(filters...)}
+	}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Compose(), input, input},
+		{Compose(Match("an"), Replace("a", "o")), input, "bonono\n"},
+		{Compose(Fields("a"), JoinFields("-")), input, "-pple\nb-n-n-\ncherry\n"},
+		{Fork(), input, ""},
+		{Fork(branch("CountLines()", CountLines())), "", "0\n"},
+		{Fork(branch("Last(1)", Last(1)), branch("First(1)", First(1))), input, "cherry\napple\n"},
+		{Fork(branch("First(1)", First(1)), branch("CountLines()", CountLines())), strings.Repeat(input, 100000), "apple\n300000\n"},
+		{Fork(branch("First(1)", First(1)), branch("First(2)", First(2))), strings.Repeat(input, 100000), "apple\napple\nbanana\n"},
+		{ForkLabel(branch("Match(\"a\") | CountLines()", Match("a"), CountLines()), branch("First(1)", First(1))), input, "Match(\"a\") | CountLines()\t2\nFirst(1)\tapple\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestForkFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{Compose(Wrap(0)), "width must be greater than 0, got: 0"},
+		{Fork(Branch{Name: "First(1)", Filter: First(1)}, Branch{Name: "Wrap(0)", Filter: Wrap(0)}), "width must be greater than 0, got: 0"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("a\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestTee(t *testing.T) {
+	t.Parallel()
+
+	input := "apple\nbanana\n"
+
+	got := &bytes.Buffer{}
+	tee := &bytes.Buffer{}
+
+	err := Tee(OutputFile{Name: "tee.txt", Writer: tee})(strings.NewReader(input), got)
+	if err != nil {
+		t.Fatalf("error executing filter: %v", err)
+	}
+
+	if input != got.String() || input != tee.String() {
+		log.Fatalf("wanted: %q, got: %q and %q", input, got.String(), tee.String())
+	}
+}
@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/dyson/pipesore/pkg/ansi"
+)
+
+// Colors are the colours of a highlight filter, supplied by the pipeline
+// rather than given as an argument. 'Start' is the index in ansi.Colors of its
+// first colour and 'NoColor' disables colouring so lines are written
+// unchanged.
+type Colors struct {
+	Start   int
+	NoColor bool
+}
+
+// color returns the i-th colour from the first colour of the highlight
+// filter, cycling through ansi.Colors.
+func (c Colors) color(i int) string {
+	return ansi.Colors[(c.Start+i)%len(ansi.Colors)]
+}
+
+// A highlight is the span of visible text from 'start' to 'end' to colour
+// with 'color'.
+type highlight struct {
+	start, end int
+	color      string
+}
+
+// Highlight returns a filter that writes all lines colouring non-overlapping
+// instances of 'substring' with the first of the 'colors'.
+func Highlight(colors Colors, substring string) func(io.Reader, io.Writer) error {
+	color := colors.color(0)
+
+	return highlightLines(colors, func(line string) []highlight {
+		highlights := []highlight{}
+		if substring == "" {
+			return highlights
+		}
+
+		for i := 0; ; {
+			start := strings.Index(line[i:], substring)
+			if start == -1 {
+				return highlights
+			}
+
+			start += i
+			i = start + len(substring)
+			highlights = append(highlights, highlight{start, i, color})
+		}
+	})
+}
+
+// HighlightRegex returns a filter that writes all lines colouring matches of
+// the compiled regular expression 'regex'. If the regex has capture groups
+// each group is coloured instead of the whole match, using the next of the
+// 'colors' for each group.
+func HighlightRegex(colors Colors, regex *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return highlightLines(colors, func(line string) []highlight {
+		highlights := []highlight{}
+		if regex.String() == "" {
+			return highlights
+		}
+
+		for _, match := range regex.FindAllStringSubmatchIndex(line, -1) {
+			if regex.NumSubexp() == 0 {
+				if match[0] != match[1] {
+					highlights = append(highlights, highlight{match[0], match[1], colors.color(0)})
+				}
+
+				continue
+			}
+
+			// skip groups that don't participate, are empty or are nested in
+			// a previous group
+			end := 0
+			for i := 1; i <= regex.NumSubexp(); i++ {
+				start := match[2*i]
+				if start == -1 || start == match[2*i+1] || start < end {
+					continue
+				}
+
+				end = match[2*i+1]
+				highlights = append(highlights, highlight{start, end, colors.color(i - 1)})
+			}
+		}
+
+		return highlights
+	})
+}
+
+// highlightLines returns a filter that writes all lines with the highlights
+// returned by 'find' coloured. Highlights are found in the visible text of each
+// line, ignoring the escape sequences of any previous highlight filter. The
+// highlight colour is kept over colours already in the line, which are
+// restored after each highlight. Lines are written unchanged if colouring is
+// disabled.
+func highlightLines(colors Colors, find func(line string) []highlight) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			if colors.NoColor {
+				fmt.Fprintln(w, scanner.Text())
+				continue
+			}
+
+			fmt.Fprintln(w, highlightLine(scanner.Text(), find))
+		}
+
+		return scanner.Err()
+	}
+}
+
+func highlightLine(line string, find func(line string) []highlight) string {
+	highlights := find(ansi.Strip(line))
+	if len(highlights) == 0 {
+		return line
+	}
+
+	starts := map[int]string{}
+	ends := map[int]bool{}
+	for _, h := range highlights {
+		starts[h.start] = h.color
+		ends[h.end] = true
+	}
+
+	var b strings.Builder
+
+	// the colours from the line and of the highlight at the current position
+	active, current := "", ""
+
+	escapes := ansi.Escapes(line)
+
+	v := 0
+	for i := 0; i < len(line); {
+		if len(escapes) > 0 && escapes[0][0] == i {
+			escape := line[escapes[0][0]:escapes[0][1]]
+			b.WriteString(escape)
+
+			switch {
+			case escape == ansi.Reset || escape == "\x1b[m":
+				active = ""
+			case strings.HasSuffix(escape, "m"):
+				active = escape
+			}
+
+			// keep the highlight colour over colours from the line
+			if current != "" && strings.HasSuffix(escape, "m") {
+				b.WriteString(current)
+			}
+
+			i = escapes[0][1]
+			escapes = escapes[1:]
+
+			continue
+		}
+
+		if color, ok := starts[v]; ok {
+			b.WriteString(color)
+			current = color
+		}
+
+		b.WriteByte(line[i])
+
+		i++
+		v++
+
+		if ends[v] {
+			b.WriteString(ansi.Reset + active)
+			current = ""
+		}
+	}
+
+	return b.String()
+}
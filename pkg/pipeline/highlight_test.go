@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHighlightFilters(t *testing.T) {
+	t.Parallel()
+
+	red, green, yellow, blue, reset := "\x1b[31m", "\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[0m"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Highlight(Colors{}, ""), "abc\n", "abc\n"},
+		{Highlight(Colors{Start: 1}, "b"), "abcb\nc\n", "a" + green + "b" + reset + "c" + green + "b" + reset + "\nc\n"},
+		{Highlight(Colors{Start: 2}, "3"), red + "a3" + reset + "\n", red + "a" + yellow + "3" + reset + red + reset + "\n"},
+		{Highlight(Colors{Start: 3}, "ab"), "x" + red + "a" + reset + "b\n", "x" + red + blue + "a" + reset + blue + "b" + reset + "\n"},
+		{HighlightRegex(Colors{Start: 4}, regexp.MustCompile("")), "abc\n", "abc\n"},
+		{HighlightRegex(Colors{Start: 5}, regexp.MustCompile("[0-9]+")), "a12b3\n", "a\x1b[36m12" + reset + "b\x1b[36m3" + reset + "\n"},
+		{HighlightRegex(Colors{Start: 6}, regexp.MustCompile(`(\w+)=(\w+)`)), "a=1 b=2\n", red + "a" + reset + "=" + green + "1" + reset + " " + red + "b" + reset + "=" + green + "2" + reset + "\n"},
+		{HighlightRegex(Colors{Start: 7}, regexp.MustCompile(`((a)b)|(c)`)), "abc\n", green + "ab" + reset + blue + "c" + reset + "\n"},
+		{Highlight(Colors{NoColor: true}, "b"), "abc\n", "abc\n"},
+		{HighlightRegex(Colors{NoColor: true}, regexp.MustCompile("b")), red + "abc" + reset + "\n", red + "abc" + reset + "\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"unicode/utf8"
+)
+
+type joinMode int
+
+const (
+	innerJoin joinMode = iota
+	leftJoin
+	antiJoin
+)
+
+// JoinOn returns a CSV aware filter that writes each line joined with every
+// line of the 'file' where the 'leftKey' column of the line equals the
+// 'rightKey' column of the 'file' line. Joined lines are the columns of the
+// line followed by the columns of the 'file' line without its key column.
+// Lines without a match, including lines without the key column, aren't
+// written. Columns are 1-indexed. The smaller of the input and the 'file' is
+// held in memory while the other is streamed.
+func JoinOn(file File, leftKey, rightKey int, delimiter string) func(io.Reader, io.Writer) error {
+	return joinOn(file, leftKey, rightKey, delimiter, innerJoin)
+}
+
+// LeftJoinOn returns a CSV aware filter that writes each line joined like
+// JoinOn but also writes lines without a match followed by empty columns.
+func LeftJoinOn(file File, leftKey, rightKey int, delimiter string) func(io.Reader, io.Writer) error {
+	return joinOn(file, leftKey, rightKey, delimiter, leftJoin)
+}
+
+// NotJoinOn returns a CSV aware filter that writes the lines where the
+// 'leftKey' column of the line doesn't equal the 'rightKey' column of any line
+// of the 'file'.
+func NotJoinOn(file File, leftKey, rightKey int, delimiter string) func(io.Reader, io.Writer) error {
+	return joinOn(file, leftKey, rightKey, delimiter, antiJoin)
+}
+
+func joinOn(file File, leftKey, rightKey int, delimiter string, mode joinMode) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		if leftKey < 1 || rightKey < 1 {
+			return fmt.Errorf("key columns must be positive ints, got: %d, %d", leftKey, rightKey)
+		}
+
+		input, smaller, err := smallerThan(r, file.Name)
+		if err != nil {
+			return err
+		}
+
+		reader := newCSVReader(input, delimiter)
+		reader.FieldsPerRecord = -1
+
+		writer := csv.NewWriter(w)
+		writer.Comma = reader.Comma
+		defer writer.Flush()
+
+		if smaller {
+			return joinInput(reader, writer, file, leftKey, rightKey, mode)
+		}
+
+		return joinFile(reader, writer, file, leftKey, rightKey, mode)
+	}
+}
+
+// smallerThan reads 'r' up to the size of the file 'name' and reports if all
+// of 'r' was read. The returned io.Reader reads all of 'r' either way. 'r' is
+// never smaller if 'name' isn't a regular file.
+func smallerThan(r io.Reader, name string) (io.Reader, bool, error) {
+	info, err := os.Stat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		return r, false, nil
+	}
+
+	buf := &bytes.Buffer{}
+
+	_, err = io.CopyN(buf, r, info.Size())
+	if err == io.EOF {
+		return buf, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return io.MultiReader(buf, r), false, nil
+}
+
+// joinFile holds the lines of the 'file' in memory while the input is streamed.
+func joinFile(reader *csv.Reader, writer *csv.Writer, file File, leftKey, rightKey int, mode joinMode) error {
+	lookup := map[string][][]string{}
+
+	width, err := readFile(file, reader.Comma, rightKey, func(key string, columns []string) {
+		lookup[key] = append(lookup[key], columns)
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		lineColumns, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var matches [][]string
+		if leftKey <= len(lineColumns) {
+			matches = lookup[lineColumns[leftKey-1]]
+		}
+
+		writeJoined(writer, lineColumns, matches, width, mode)
+	}
+
+	return nil
+}
+
+// joinInput holds the lines of the input in memory while the 'file' is
+// streamed, keeping only the 'file' lines that match.
+func joinInput(reader *csv.Reader, writer *csv.Writer, file File, leftKey, rightKey int, mode joinMode) error {
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	lookup := map[string][][]string{}
+	for _, lineColumns := range lines {
+		if leftKey <= len(lineColumns) {
+			lookup[lineColumns[leftKey-1]] = nil
+		}
+	}
+
+	width, err := readFile(file, reader.Comma, rightKey, func(key string, columns []string) {
+		matches, ok := lookup[key]
+		// a single match is enough to exclude a line
+		if !ok || (mode == antiJoin && len(matches) > 0) {
+			return
+		}
+
+		lookup[key] = append(matches, columns)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, lineColumns := range lines {
+		var matches [][]string
+		if leftKey <= len(lineColumns) {
+			matches = lookup[lineColumns[leftKey-1]]
+		}
+
+		writeJoined(writer, lineColumns, matches, width, mode)
+	}
+
+	return nil
+}
+
+// readFile splits each line of the 'file' with 'comma' and calls 'fn' with the
+// 'rightKey' column and the remaining columns of the lines that have the key
+// column. It returns the most remaining columns of any line.
+func readFile(file File, comma rune, rightKey int, fn func(key string, columns []string)) (int, error) {
+	width := 0
+
+	// lines may have different numbers of columns
+	fileReader := newCSVReader(file, string(comma))
+	fileReader.FieldsPerRecord = -1
+
+	for {
+		lineColumns, err := fileReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error reading %s: %w", file.Name, err)
+		}
+
+		if rightKey > len(lineColumns) {
+			continue
+		}
+
+		key := lineColumns[rightKey-1]
+		fn(key, slices.Delete(lineColumns, rightKey-1, rightKey))
+		width = max(width, len(lineColumns)-1)
+	}
+
+	return width, nil
+}
+
+func writeJoined(writer *csv.Writer, lineColumns []string, matches [][]string, width int, mode joinMode) {
+	switch {
+	case mode == antiJoin:
+		if len(matches) == 0 {
+			writer.Write(lineColumns)
+		}
+	case len(matches) > 0:
+		for _, match := range matches {
+			writer.Write(append(slices.Clip(lineColumns), match...))
+		}
+	case mode == leftJoin:
+		writer.Write(append(slices.Clip(lineColumns), make([]string, width)...))
+	}
+}
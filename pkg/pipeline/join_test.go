@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJoinFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "1,apple\n2,banana\n3,cherry\n"
+
+	lookup := func(s string) File {
+		return File{Name: "lookup.csv", Reader: strings.NewReader(s)}
+	}
+
+	colours := "red,1\nyellow,2\ngreen,1\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{JoinOn(lookup(""), 1, 1, ","), input, ""},
+		{JoinOn(lookup(colours), 1, 2, ","), input, "1,apple,red\n1,apple,green\n2,banana,yellow\n"},
+		{JoinOn(lookup("apple\t\"a,b\"\n"), 2, 1, "\t"), "1\tapple\n", "1\tapple\ta,b\n"},
+		{JoinOn(lookup("1,x\n"), 3, 1, ","), input, ""},
+		{JoinOn(lookup("1,a\n2\n3,c,d\n"), 1, 1, ","), "1,x\n2,y\n3\n", "1,x,a\n2,y\n3,c,d\n"},
+		{JoinOn(lookup("a\nb,1\n"), 2, 2, ","), "x,1\ny\n", "x,1,b\n"},
+		{LeftJoinOn(lookup("1,a\n"), 2, 1, ","), "x,1\ny\n", "x,1,a\ny,\n"},
+		{NotJoinOn(lookup("1,a\n"), 2, 1, ","), "x,1\ny\n", "y\n"},
+		{LeftJoinOn(lookup(colours), 1, 2, ","), input, "1,apple,red\n1,apple,green\n2,banana,yellow\n3,cherry,\n"},
+		{LeftJoinOn(lookup("1,a,b\n"), 1, 1, ","), "1\n2\n", "1,a,b\n2,,\n"},
+		{NotJoinOn(lookup(colours), 1, 2, ","), input, "3,cherry\n"},
+		{NotJoinOn(lookup(""), 1, 1, ","), input, input},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestJoinFiltersSmallerInput(t *testing.T) {
+	t.Parallel()
+
+	input := "1,apple\n2,banana\n3,cherry\n"
+
+	dir := t.TempDir()
+
+	// the file is padded with lines that never match so that it's larger than
+	// the input and the input is held in memory instead
+	lookup := func(s string) File {
+		f, err := os.CreateTemp(dir, "lookup-*.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		s += strings.Repeat("z\n", 100)
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+
+		return File{Name: f.Name(), Reader: strings.NewReader(s)}
+	}
+
+	colours := "red,1\nyellow,2\ngreen,1\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{JoinOn(lookup(colours), 1, 2, ","), input, "1,apple,red\n1,apple,green\n2,banana,yellow\n"},
+		{JoinOn(lookup("1,a\n"), 1, 1, ","), "1,x\n2,y\n1,z\n", "1,x,a\n1,z,a\n"},
+		{JoinOn(lookup("1,x\n"), 3, 1, ","), input, ""},
+		{LeftJoinOn(lookup(colours), 1, 2, ","), input, "1,apple,red\n1,apple,green\n2,banana,yellow\n3,cherry,\n"},
+		{LeftJoinOn(lookup("1,a,b\n"), 1, 1, ","), "1\n2\n", "1,a,b\n2,,\n"},
+		{NotJoinOn(lookup(colours), 1, 2, ","), input, "3,cherry\n"},
+		{NotJoinOn(lookup(""), 1, 1, ","), input, input},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestJoinFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	lookup := File{Name: "lookup.csv", Reader: strings.NewReader("1,a\n")}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{JoinOn(lookup, 0, 1, ","), "key columns must be positive ints, got: 0, 1"},
+		{JoinOn(lookup, 1, 1, ",,"), "delimeter must be a single rune, got: ,,"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("1,b\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
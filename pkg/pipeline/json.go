@@ -0,0 +1,606 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// An InvalidPolicy defines what a filter does with a line it can't parse.
+type InvalidPolicy int
+
+const (
+	// SkipInvalid drops lines that can't be parsed.
+	SkipInvalid InvalidPolicy = iota
+	// ErrorInvalid stops the pipeline with an error.
+	ErrorInvalid
+	// PassInvalid writes lines that can't be parsed unchanged.
+	PassInvalid
+)
+
+// ParseInvalidPolicy returns the InvalidPolicy named 'policy': "skip", "error"
+// or "pass".
+func ParseInvalidPolicy(policy string) (InvalidPolicy, error) {
+	switch policy {
+	case "skip":
+		return SkipInvalid, nil
+	case "error":
+		return ErrorInvalid, nil
+	case "pass":
+		return PassInvalid, nil
+	}
+
+	return 0, fmt.Errorf("invalid policy must be one of \"skip\", \"error\" or \"pass\", got: %q", policy)
+}
+
+// handle applies the policy to the invalid 'line'. It returns an error if the
+// policy is ErrorInvalid.
+func (p InvalidPolicy) handle(w io.Writer, n int, line string, err error) error {
+	switch p {
+	case ErrorInvalid:
+		return fmt.Errorf("invalid line %d: %w", n, err)
+	case PassInvalid:
+		fmt.Fprintln(w, line)
+	}
+
+	return nil
+}
+
+// A JSONPath selects a value in a JSON document. Paths are made up of
+// '.key', '["key"]' and '[index]' segments, for example '.users[0].name'.
+// Negative indexes count back from the end of an array. The path '.' selects
+// the whole document.
+type JSONPath struct {
+	path     string
+	segments []any
+}
+
+// ParseJSONPath returns the JSONPath for 'path'.
+func ParseJSONPath(path string) (*JSONPath, error) {
+	paths, err := ParseJSONPaths(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) != 1 {
+		return nil, fmt.Errorf("expected a single JSON path, got: %s", path)
+	}
+
+	return paths[0], nil
+}
+
+// ParseJSONPaths returns the JSONPaths for 'paths', a comma separated list of
+// paths.
+func ParseJSONPaths(paths string) ([]*JSONPath, error) {
+	parsed := []*JSONPath{}
+
+	i := 0
+	for {
+		for i < len(paths) && paths[i] == ' ' {
+			i++
+		}
+
+		start := i
+
+		if i >= len(paths) || paths[i] != '.' && paths[i] != '[' {
+			return nil, fmt.Errorf("JSON path must start with '.' or '[' at position %d, got: %s", i+1, paths)
+		}
+
+		p := &JSONPath{}
+
+		if paths[i] == '.' && (i+1 == len(paths) || paths[i+1] == ',' || paths[i+1] == ' ') {
+			i++
+		}
+
+		for i < len(paths) && paths[i] != ',' && paths[i] != ' ' {
+			switch paths[i] {
+			case '.':
+				i++
+
+				if i < len(paths) && paths[i] == '[' {
+					continue
+				}
+
+				j := i
+				for j < len(paths) && !strings.ContainsRune(".[], ", rune(paths[j])) {
+					j++
+				}
+
+				if j == i {
+					return nil, fmt.Errorf("JSON path has an empty key at position %d, got: %s", i+1, paths)
+				}
+
+				p.segments = append(p.segments, paths[i:j])
+				i = j
+
+			case '[':
+				end := strings.IndexByte(paths[i:], ']')
+				if strings.HasPrefix(paths[i+1:], "\"") {
+					quoted, err := strconv.QuotedPrefix(paths[i+1:])
+					if err != nil {
+						return nil, fmt.Errorf("JSON path has an invalid quoted key at position %d, got: %s", i+2, paths)
+					}
+
+					end = len(quoted) + 1
+				}
+
+				if end == -1 || i+end >= len(paths) || paths[i+end] != ']' {
+					return nil, fmt.Errorf("JSON path has an unterminated '[' at position %d, got: %s", i+1, paths)
+				}
+
+				inner := paths[i+1 : i+end]
+
+				if key, err := strconv.Unquote(inner); err == nil && inner[0] == '"' {
+					p.segments = append(p.segments, key)
+				} else if index, err := strconv.Atoi(inner); err == nil {
+					p.segments = append(p.segments, index)
+				} else {
+					return nil, fmt.Errorf("JSON path index must be an int or quoted key at position %d, got: %s", i+2, paths)
+				}
+
+				i += end + 1
+
+			default:
+				return nil, fmt.Errorf("JSON path has an unexpected '%c' at position %d, got: %s", paths[i], i+1, paths)
+			}
+		}
+
+		p.path = paths[start:i]
+		parsed = append(parsed, p)
+
+		for i < len(paths) && paths[i] == ' ' {
+			i++
+		}
+
+		if i == len(paths) {
+			break
+		}
+
+		if paths[i] != ',' {
+			return nil, fmt.Errorf("JSON paths must be comma separated at position %d, got: %s", i+1, paths)
+		}
+		i++
+	}
+
+	return parsed, nil
+}
+
+func (p *JSONPath) String() string {
+	return p.path
+}
+
+// get returns the value selected by the path in 'doc'.
+func (p *JSONPath) get(doc any) (any, bool) {
+	v := doc
+	for _, segment := range p.segments {
+		switch segment := segment.(type) {
+		case string:
+			o, ok := v.(*jsonObject)
+			if !ok {
+				return nil, false
+			}
+
+			v, ok = o.values[segment]
+			if !ok {
+				return nil, false
+			}
+
+		case int:
+			a, ok := v.([]any)
+			if !ok {
+				return nil, false
+			}
+
+			index, ok := arrayIndex(a, segment)
+			if !ok {
+				return nil, false
+			}
+
+			v = a[index]
+		}
+	}
+
+	return v, true
+}
+
+// set returns 'doc' with the value selected by the path set to 'value'.
+// Missing object keys are created.
+func (p *JSONPath) set(doc any, value any) (any, error) {
+	return p.update(doc, p.segments, func(any) (any, bool) {
+		return value, true
+	})
+}
+
+// delete returns 'doc' with the value selected by the path removed.
+func (p *JSONPath) delete(doc any) (any, error) {
+	return p.update(doc, p.segments, func(any) (any, bool) {
+		return nil, false
+	})
+}
+
+// update replaces the value selected by 'segments' in 'v' with the result of
+// 'fn', which is passed the current value. The value is removed if 'fn'
+// returns false.
+func (p *JSONPath) update(v any, segments []any, fn func(any) (any, bool)) (any, error) {
+	if len(segments) == 0 {
+		value, _ := fn(v)
+		return value, nil
+	}
+
+	switch segment := segments[0].(type) {
+	case string:
+		o, ok := v.(*jsonObject)
+		if !ok {
+			if v != nil {
+				return nil, fmt.Errorf("path %s: expected an object, got: %s", p, encodeJSON(v))
+			}
+
+			o = newJSONObject()
+		}
+
+		current := o.values[segment]
+		if len(segments) == 1 {
+			value, keep := fn(current)
+			if keep {
+				o.set(segment, value)
+			} else {
+				o.delete(segment)
+			}
+
+			return o, nil
+		}
+
+		value, err := p.update(current, segments[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		o.set(segment, value)
+
+		return o, nil
+
+	case int:
+		a, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("path %s: expected an array, got: %s", p, encodeJSON(v))
+		}
+
+		index, ok := arrayIndex(a, segment)
+		if !ok {
+			return nil, fmt.Errorf("path %s: index %d out of range", p, segment)
+		}
+
+		if len(segments) == 1 {
+			value, keep := fn(a[index])
+			if !keep {
+				return append(a[:index:index], a[index+1:]...), nil
+			}
+
+			a[index] = value
+
+			return a, nil
+		}
+
+		value, err := p.update(a[index], segments[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		a[index] = value
+
+		return a, nil
+	}
+
+	return v, nil
+}
+
+func arrayIndex(a []any, index int) (int, bool) {
+	if index < 0 {
+		index += len(a)
+	}
+
+	return index, index >= 0 && index < len(a)
+}
+
+// A jsonObject is a JSON object that keeps the order of its keys so filters
+// that modify objects don't reorder them.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]any{}}
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+
+	o.values[key] = value
+}
+
+func (o *jsonObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// decodeJSON returns the JSON value in 'line'. Objects are decoded as
+// *jsonObject and numbers as json.Number.
+func decodeJSON(line string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	v, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+
+	return v, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder) (any, error) {
+	t, err := decoder.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		o := newJSONObject()
+		for decoder.More() {
+			t, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			o.set(t.(string), value)
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return o, nil
+
+	case json.Delim('['):
+		a := []any{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			a = append(a, value)
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return a, nil
+	}
+
+	return t, nil
+}
+
+// encodeJSON returns the compact JSON encoding of 'v'.
+func encodeJSON(v any) string {
+	buf := &bytes.Buffer{}
+	writeJSON(buf, v)
+
+	return buf.String()
+}
+
+func writeJSON(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case *jsonObject:
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeJSON(buf, key)
+			buf.WriteByte(':')
+			writeJSON(buf, v.values[key])
+		}
+		buf.WriteByte('}')
+
+	case []any:
+		buf.WriteByte('[')
+		for i, value := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeJSON(buf, value)
+		}
+		buf.WriteByte(']')
+
+	default:
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(v)
+		buf.Truncate(buf.Len() - 1)
+	}
+}
+
+// jsonText returns strings as is and all other values as JSON.
+func jsonText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return encodeJSON(v)
+}
+
+// jsonLines calls 'fn' with the decoded JSON of each line read, applying the
+// 'invalid' policy to lines that aren't valid JSON.
+func jsonLines(r io.Reader, w io.Writer, invalid InvalidPolicy, fn func(line string, doc any) error) error {
+	scanner := bufio.NewScanner(r)
+
+	n := 0
+	for scanner.Scan() {
+		n++
+
+		doc, err := decodeJSON(scanner.Text())
+		if err != nil {
+			if err := invalid.handle(w, n, scanner.Text(), err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := fn(scanner.Text(), doc); err != nil {
+			if err := invalid.handle(w, n, scanner.Text(), err); err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// JSONGet returns a filter that writes the value selected by 'path' from each
+// line of JSON. Strings are written without quotes and all other values as
+// JSON. Lines without the value are skipped.
+func JSONGet(path *JSONPath, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			if v, ok := path.get(doc); ok {
+				fmt.Fprintln(w, jsonText(v))
+			}
+
+			return nil
+		})
+	}
+}
+
+// JSONWhere returns a filter that writes lines of JSON where the value
+// selected by 'path' is equal to 'value'. Strings are compared without quotes
+// and all other values as JSON.
+func JSONWhere(path *JSONPath, value string, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			if v, ok := path.get(doc); ok && jsonText(v) == value {
+				fmt.Fprintln(w, line)
+			}
+
+			return nil
+		})
+	}
+}
+
+// NotJSONWhere returns a filter that writes lines of JSON where the value
+// selected by 'path' isn't equal to 'value'. Strings are compared without
+// quotes and all other values as JSON.
+func NotJSONWhere(path *JSONPath, value string, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			if v, ok := path.get(doc); !ok || jsonText(v) != value {
+				fmt.Fprintln(w, line)
+			}
+
+			return nil
+		})
+	}
+}
+
+// JSONSet returns a filter that writes each line of JSON with the value
+// selected by 'path' set to 'value'. If 'value' is valid JSON it's set as is,
+// otherwise it's set as a string. Missing object keys are created.
+func JSONSet(path *JSONPath, value string, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			v, err := decodeJSON(value)
+			if err != nil {
+				v = value
+			}
+
+			doc, err = path.set(doc, v)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(w, encodeJSON(doc))
+
+			return nil
+		})
+	}
+}
+
+// JSONDelete returns a filter that writes each line of JSON with the value
+// selected by 'path' removed. The whole line (the path ".") can't be removed.
+func JSONDelete(path *JSONPath, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if len(path.segments) == 0 {
+			return fmt.Errorf("path to delete must not be the whole line, got: %s", path.path)
+		}
+
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			if _, ok := path.get(doc); ok {
+				var err error
+				doc, err = path.delete(doc)
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintln(w, encodeJSON(doc))
+
+			return nil
+		})
+	}
+}
+
+// JSONToColumns returns a filter that writes the values selected by 'paths'
+// from each line of JSON separated by 'delimiter'. Missing values are written
+// as empty columns.
+func JSONToColumns(paths []*JSONPath, delimiter string, invalid InvalidPolicy) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return jsonLines(r, w, invalid, func(line string, doc any) error {
+			columns := []string{}
+			for _, path := range paths {
+				column := ""
+				if v, ok := path.get(doc); ok {
+					column = jsonText(v)
+				}
+
+				columns = append(columns, column)
+			}
+
+			fmt.Fprintln(w, strings.Join(columns, delimiter))
+
+			return nil
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseJSONPath(path string) *JSONPath {
+	p, err := ParseJSONPath(path)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+func TestParseJSONPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		paths string
+		want  [][]any
+		err   bool
+	}{
+		{".", [][]any{nil}, false},
+		{".a.b", [][]any{{"a", "b"}}, false},
+		{".a[0][-1]", [][]any{{"a", 0, -1}}, false},
+		{`.["a.b"].c`, [][]any{{"a.b", "c"}}, false},
+		{`["a,b"]`, [][]any{{"a,b"}}, false},
+		{".a, .b[1],.", [][]any{{"a"}, {"b", 1}, nil}, false},
+		{"", nil, true},
+		{"a", nil, true},
+		{".a..b", nil, true},
+		{".a[", nil, true},
+		{".a[b]", nil, true},
+		{".a .b", nil, true},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			paths, err := ParseJSONPaths(tc.paths)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("(test %d) wanted error parsing %q", k, tc.paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("(test %d) error parsing %q: %v", k, tc.paths, err)
+			}
+
+			got := [][]any{}
+			for _, p := range paths {
+				got = append(got, p.segments)
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("(test %d) wanted: %#v, got: %#v", k, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestJSONFilters(t *testing.T) {
+	t.Parallel()
+
+	input := `{"level":"error","user":{"id":1,"name":"a<b"},"tags":["x","y"]}` + "\n" +
+		"not json\n" +
+		`{"level":"info","user":{"id":2}}` + "\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{JSONGet(mustParseJSONPath(".user.id"), SkipInvalid), input, "1\n2\n"},
+		{JSONGet(mustParseJSONPath(".user.name"), PassInvalid), input, "a<b\nnot json\n"},
+		{JSONGet(mustParseJSONPath(".tags"), SkipInvalid), input, "[\"x\",\"y\"]\n"},
+		{JSONGet(mustParseJSONPath(".tags[-1]"), SkipInvalid), input, "y\n"},
+		{JSONWhere(mustParseJSONPath(".level"), "info", SkipInvalid), input, `{"level":"info","user":{"id":2}}` + "\n"},
+		{JSONWhere(mustParseJSONPath(".user.id"), "1", SkipInvalid), input, strings.SplitAfter(input, "\n")[0]},
+		{NotJSONWhere(mustParseJSONPath(".level"), "error", PassInvalid), input, "not json\n" + `{"level":"info","user":{"id":2}}` + "\n"},
+		{JSONSet(mustParseJSONPath(".user.id"), "x", SkipInvalid), `{"b":1,"user":{"id":1,"a":2}}`, `{"b":1,"user":{"id":"x","a":2}}` + "\n"},
+		{JSONSet(mustParseJSONPath(".a.b"), `{"c":[1]}`, SkipInvalid), `{}`, `{"a":{"b":{"c":[1]}}}` + "\n"},
+		{JSONSet(mustParseJSONPath(".a.b"), "1", PassInvalid), `{"a":[]}`, `{"a":[]}` + "\n"},
+		{JSONDelete(mustParseJSONPath(".user"), SkipInvalid), input, `{"level":"error","tags":["x","y"]}` + "\n" + `{"level":"info"}` + "\n"},
+		{JSONDelete(mustParseJSONPath(".tags[0]"), SkipInvalid), `{"tags":["x","y"]}`, `{"tags":["y"]}` + "\n"},
+		{JSONToColumns([]*JSONPath{mustParseJSONPath(".user.id"), mustParseJSONPath(".level"), mustParseJSONPath(".tags[0]")}, "\t", SkipInvalid), input, "1\terror\tx\n2\tinfo\t\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+
+	err := JSONGet(mustParseJSONPath("."), ErrorInvalid)(strings.NewReader(input), io.Discard)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid line 2:") {
+		t.Fatalf("wanted invalid line 2 error, got: %v", err)
+	}
+
+	err = JSONDelete(mustParseJSONPath("."), SkipInvalid)(strings.NewReader(input), io.Discard)
+	if err == nil || err.Error() != "path to delete must not be the whole line, got: ." {
+		t.Fatalf("wanted whole line error, got: %v", err)
+	}
+}
@@ -10,10 +10,12 @@ func NewPipeline(r io.Reader) *pipeline {
 	return &pipeline{r: r}
 }
 
-// A pipeline contains the io.Reader the next filter is to read from as well as
-// a mutex protected error for all filter errors to be written to.
+// A pipeline contains the io.Reader or Record channel the next filter is to
+// read from as well as a mutex protected error for all filter errors to be
+// written to.
 type pipeline struct {
-	r io.Reader
+	r       io.Reader
+	records <-chan Record
 
 	err error
 	mu  sync.Mutex
@@ -38,13 +40,73 @@ func (p *pipeline) Filter(filter func(io.Reader, io.Writer) error) {
 	go func() {
 		defer pw.Close()
 
-		err := filter(r, pw)
-		p.SetError(err)
+		if err := filter(r, pw); err != nil {
+			p.SetError(err)
+		}
 	}()
 
 	p.r = pr
 }
 
+// ToRecords takes a filter function that splits the current pipelines
+// io.Reader into records. It writes the records to a channel and sets the
+// pipelines Record channel ready for the next filter to consume. If the filter
+// errors the error is set on the pipeline.
+func (p *pipeline) ToRecords(filter func(io.Reader, chan<- Record) error) {
+	r := p.r
+	records := make(chan Record)
+
+	go func() {
+		defer close(records)
+
+		if err := filter(r, records); err != nil {
+			p.SetError(err)
+		}
+	}()
+
+	p.r = nil
+	p.records = records
+}
+
+// FilterRecords takes a filter function and filters the current pipelines
+// Record channel with it. It writes the output to a new Record channel ready
+// for the next filter to consume. If the filter errors the error is set on the
+// pipeline.
+func (p *pipeline) FilterRecords(filter func(<-chan Record, chan<- Record) error) {
+	in := p.records
+	records := make(chan Record)
+
+	go func() {
+		defer close(records)
+
+		if err := filter(in, records); err != nil {
+			p.SetError(err)
+		}
+	}()
+
+	p.records = records
+}
+
+// FromRecords takes a filter function that serialises the current pipelines
+// Record channel. It writes the output to an io.Pipe() and sets the pipelines
+// io.Reader to the io.Pipe io.Reader ready for the next filter to consume. If
+// the filter errors the error is set on the pipeline.
+func (p *pipeline) FromRecords(filter func(<-chan Record, io.Writer) error) {
+	in := p.records
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer pw.Close()
+
+		if err := filter(in, pw); err != nil {
+			p.SetError(err)
+		}
+	}()
+
+	p.r = pr
+	p.records = nil
+}
+
 // Output copies from the pipelines io.Reader and writes it to the provided
 // io.Writer.
 func (p *pipeline) Output(out io.Writer) (int64, error) {
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"math"
+	"sort"
+)
+
+// A quantile estimates the p-quantile of a stream of numbers in constant
+// memory using the P² algorithm (Jain and Chlamtac, 1985). The first five
+// numbers are kept so small inputs return an exact quantile.
+type quantile struct {
+	p       float64
+	initial []float64
+
+	heights   [5]float64
+	positions [5]float64
+	desired   [5]float64
+	increment [5]float64
+}
+
+func newQuantile(p float64) *quantile {
+	return &quantile{
+		p:         p,
+		increment: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+func (q *quantile) add(x float64) {
+	if len(q.initial) < 5 {
+		q.initial = append(q.initial, x)
+		return
+	}
+
+	if len(q.initial) == 5 && q.positions[4] == 0 {
+		sorted := append([]float64{}, q.initial...)
+		sort.Float64s(sorted)
+
+		copy(q.heights[:], sorted)
+		q.positions = [5]float64{1, 2, 3, 4, 5}
+		q.desired = [5]float64{1, 1 + 2*q.p, 1 + 4*q.p, 3 + 2*q.p, 5}
+	}
+
+	// find the cell k that x falls in, adjusting the extreme heights
+	var k int
+	switch {
+	case x < q.heights[0]:
+		q.heights[0] = x
+		k = 0
+	case x >= q.heights[4]:
+		q.heights[4] = x
+		k = 3
+	default:
+		for k = 0; k < 3 && x >= q.heights[k+1]; k++ {
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		q.positions[i]++
+	}
+
+	for i := range q.desired {
+		q.desired[i] += q.increment[i]
+	}
+
+	// adjust the heights of the middle markers if they're off their desired
+	// positions
+	for i := 1; i < 4; i++ {
+		d := q.desired[i] - q.positions[i]
+
+		if d >= 1 && q.positions[i+1]-q.positions[i] > 1 || d <= -1 && q.positions[i-1]-q.positions[i] < -1 {
+			d = math.Copysign(1, d)
+
+			h := q.parabolic(i, d)
+			if q.heights[i-1] < h && h < q.heights[i+1] {
+				q.heights[i] = h
+			} else {
+				q.heights[i] = q.linear(i, d)
+			}
+
+			q.positions[i] += d
+		}
+	}
+}
+
+func (q *quantile) parabolic(i int, d float64) float64 {
+	n, h := q.positions, q.heights
+
+	return h[i] + d/(n[i+1]-n[i-1])*((n[i]-n[i-1]+d)*(h[i+1]-h[i])/(n[i+1]-n[i])+(n[i+1]-n[i]-d)*(h[i]-h[i-1])/(n[i]-n[i-1]))
+}
+
+func (q *quantile) linear(i int, d float64) float64 {
+	j := i + int(d)
+
+	return q.heights[i] + d*(q.heights[j]-q.heights[i])/(q.positions[j]-q.positions[i])
+}
+
+// value returns the estimated quantile or false if no numbers were added.
+func (q *quantile) value() (float64, bool) {
+	if len(q.initial) == 0 {
+		return 0, false
+	}
+
+	// the extreme heights are the exact minimum and maximum
+	if q.positions[4] != 0 {
+		switch q.p {
+		case 0:
+			return q.heights[0], true
+		case 1:
+			return q.heights[4], true
+		}
+
+		return q.heights[2], true
+	}
+
+	// exact quantile using linear interpolation between the closest ranks
+	sorted := append([]float64{}, q.initial...)
+	sort.Float64s(sorted)
+
+	rank := q.p * float64(len(sorted)-1)
+	lower := int(rank)
+
+	if lower+1 == len(sorted) {
+		return sorted[lower], true
+	}
+
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower]), true
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Between returns a filter that writes each block of lines from a line
+// matching the compiled regular expression 'start' to the next line matching
+// the compiled regular expression 'end', including the matching lines. A block
+// without an end line continues to the end of the input.
+func Between(start, end *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return between(start, end, true, false)
+}
+
+// NotBetween returns a filter that writes lines outside of the blocks written
+// by Between.
+func NotBetween(start, end *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return between(start, end, true, true)
+}
+
+// BetweenExclusive returns a filter that writes each block of lines between a
+// line matching the compiled regular expression 'start' and the next line
+// matching the compiled regular expression 'end', excluding the matching
+// lines. A block without an end line continues to the end of the input.
+func BetweenExclusive(start, end *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return between(start, end, false, false)
+}
+
+// NotBetweenExclusive returns a filter that writes lines outside of the blocks
+// written by BetweenExclusive.
+func NotBetweenExclusive(start, end *regexp.Regexp) func(io.Reader, io.Writer) error {
+	return between(start, end, false, true)
+}
+
+func between(start, end *regexp.Regexp, inclusive, not bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		inBlock := false
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			// the end of a block is only matched from the line after the start
+			// of the block so a line matching both doesn't close its own block
+			var selected bool
+			switch {
+			case !inBlock && start.MatchString(scanner.Text()):
+				inBlock = true
+				selected = inclusive
+			case inBlock && end.MatchString(scanner.Text()):
+				inBlock = false
+				selected = inclusive
+			default:
+				selected = inBlock
+			}
+
+			if selected != not {
+				fmt.Fprintln(w, scanner.Text())
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Lines returns a filter that writes the lines selected by 'ranges', a comma
+// separated list of 1-indexed line numbers and inclusive ranges of line
+// numbers, for example "100-200,300". A range without an end, for example
+// "300-", continues to the end of the input. The input is only read up to the
+// last selected line unless a range has no end.
+func Lines(ranges string) func(io.Reader, io.Writer) error {
+	return lines(ranges, false)
+}
+
+// NotLines returns a filter that writes the lines not selected by 'ranges'.
+func NotLines(ranges string) func(io.Reader, io.Writer) error {
+	return lines(ranges, true)
+}
+
+func lines(ranges string, not bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		selected, last, err := parseRanges(ranges)
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			if selected(n) != not {
+				fmt.Fprintln(w, scanner.Text())
+			}
+
+			// no lines after the last selected line are written
+			if !not && n == last {
+				return nil
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// parseRanges returns a function reporting whether a 1-indexed line number is
+// selected by 'ranges', a comma separated list of line numbers and inclusive
+// ranges of line numbers, and the last selected line number or -1 if a range
+// has no end.
+func parseRanges(ranges string) (func(n int) bool, int, error) {
+	type lineRange struct {
+		start, end int
+	}
+
+	parsed := []lineRange{}
+	last := 0
+	for _, r := range strings.Split(ranges, ",") {
+		s, e, isRange := strings.Cut(strings.TrimSpace(r), "-")
+
+		start, err := strconv.Atoi(s)
+		if err != nil || start < 1 {
+			return nil, 0, fmt.Errorf("list of lines must be comma separated list of positive ints or ranges, got: %v", ranges)
+		}
+
+		end := start
+		switch {
+		case isRange && e == "":
+			end = -1
+		case isRange:
+			end, err = strconv.Atoi(e)
+			if err != nil || end < start {
+				return nil, 0, fmt.Errorf("list of lines must be comma separated list of positive ints or ranges, got: %v", ranges)
+			}
+		}
+
+		parsed = append(parsed, lineRange{start, end})
+
+		if last != -1 && (end == -1 || end > last) {
+			last = end
+		}
+	}
+
+	return func(n int) bool {
+		for _, r := range parsed {
+			if n >= r.start && (r.end == -1 || n <= r.end) {
+				return true
+			}
+		}
+
+		return false
+	}, last, nil
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRangeFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "a\nBEGIN\nb\nEND\nc\nBEGIN\nd\nEND\ne\n"
+	begin, end := regexp.MustCompile("^BEGIN"), regexp.MustCompile("^END")
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Between(begin, end), input, "BEGIN\nb\nEND\nBEGIN\nd\nEND\n"},
+		{Between(begin, end), "a\nBEGIN\nb\n", "BEGIN\nb\n"},
+		{Between(regexp.MustCompile("x"), regexp.MustCompile("x")), "x\n1\nx\n2\n", "x\n1\nx\n"},
+		{NotBetween(begin, end), input, "a\nc\ne\n"},
+		{BetweenExclusive(begin, end), input, "b\nd\n"},
+		{NotBetweenExclusive(begin, end), input, "a\nBEGIN\nEND\nc\nBEGIN\nEND\ne\n"},
+		{Lines("2"), input, "BEGIN\n"},
+		{Lines("2-3, 9"), input, "BEGIN\nb\ne\n"},
+		{Lines("8-"), input, "END\ne\n"},
+		{Lines("1-2,2-3"), input, "a\nBEGIN\nb\n"},
+		{Lines("20"), input, ""},
+		{NotLines("2-8"), input, "a\ne\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestRangeFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{Lines(""), "list of lines must be comma separated list of positive ints or ranges, got: "},
+		{Lines("0"), "list of lines must be comma separated list of positive ints or ranges, got: 0"},
+		{Lines("5-2"), "list of lines must be comma separated list of positive ints or ranges, got: 5-2"},
+		{NotLines("a-b"), "list of lines must be comma separated list of positive ints or ranges, got: a-b"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("a\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
+
+// endless is an input that never ends.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = "x\n"[i%2]
+	}
+
+	return len(p) - len(p)%2, nil
+}
+
+func TestLinesEndlessInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{Lines("2-3"), "x\nx\n"},
+		{Lines("1,3-4,2"), "x\nx\nx\nx\n"},
+		{Compose(Lines("5"), Upper()), "X\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(endless{}, got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v", k, err)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Record is a line of input that has been split into fields. Filters that
+// operate on records read and write Record channels instead of lines of text
+// so fields only need to be split once.
+type Record []string
+
+// field returns the 1-indexed 'column' of the record or an empty string if the
+// record has less than 'column' fields.
+func (r Record) field(column int) string {
+	if column < 1 || column > len(r) {
+		return ""
+	}
+
+	return r[column-1]
+}
+
+// Fields returns a filter that writes each line as a record of fields defined
+// by splitting with the 'delimiter'.
+func Fields(delimiter string) func(io.Reader, chan<- Record) error {
+	return func(r io.Reader, records chan<- Record) error {
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			records <- strings.Split(scanner.Text(), delimiter)
+		}
+
+		return scanner.Err()
+	}
+}
+
+// CSV returns a CSV aware filter that writes each line as a record of fields
+// defined by splitting with the 'delimiter'.
+func CSV(delimiter string) func(io.Reader, chan<- Record) error {
+	return func(r io.Reader, records chan<- Record) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		reader := newCSVReader(r, delimiter)
+		reader.FieldsPerRecord = -1
+
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			records <- record
+		}
+
+		return nil
+	}
+}
+
+// MatchField returns a filter that writes records where the field in 'column'
+// contains 'substring'.
+func MatchField(column int, substring string) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		for record := range in {
+			if strings.Contains(record.field(column), substring) {
+				out <- record
+			}
+		}
+
+		return nil
+	}
+}
+
+// NotMatchField returns a filter that writes records where the field in
+// 'column' doesn't contain 'substring'.
+func NotMatchField(column int, substring string) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		for record := range in {
+			if !strings.Contains(record.field(column), substring) {
+				out <- record
+			}
+		}
+
+		return nil
+	}
+}
+
+// ReplaceField returns a filter that writes all records replacing
+// non-overlapping instances of 'old' with 'replace' in the field in 'column'.
+func ReplaceField(column int, old, replace string) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		for record := range in {
+			if column >= 1 && column <= len(record) {
+				record[column-1] = strings.ReplaceAll(record[column-1], old, replace)
+			}
+
+			out <- record
+		}
+
+		return nil
+	}
+}
+
+// SelectFields returns a filter that writes records of the selected 'columns'
+// in the order provided where 'columns' is a 1-indexed comma separated list of
+// column positions.
+func SelectFields(columns string) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		order, err := parseColumns(columns)
+		if err != nil {
+			return err
+		}
+
+		for record := range in {
+			output := Record{}
+			for _, v := range order {
+				if v-1 < len(record) {
+					output = append(output, record[v-1])
+				}
+			}
+
+			out <- output
+		}
+
+		return nil
+	}
+}
+
+// SortField returns a filter that writes records sorted alphabetically by the
+// field in 'column'. Records with equal fields keep their input order.
+func SortField(column int) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		records := []Record{}
+		for record := range in {
+			records = append(records, record)
+		}
+
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].field(column) < records[j].field(column)
+		})
+
+		for _, record := range records {
+			out <- record
+		}
+
+		return nil
+	}
+}
+
+// FrequencyField returns a filter that writes a record of frequency count and
+// field for each unique field in 'column' in descending numerical order (most
+// frequent fields first). Fields with equal frequency will be sorted
+// alphabetically.
+func FrequencyField(column int) func(<-chan Record, chan<- Record) error {
+	return func(in <-chan Record, out chan<- Record) error {
+		freq := map[string]int{}
+
+		for record := range in {
+			freq[record.field(column)]++
+		}
+
+		fields := make([]string, 0, len(freq))
+		for field := range freq {
+			fields = append(fields, field)
+		}
+
+		sort.Slice(fields, func(i, j int) bool {
+			x, y := freq[fields[i]], freq[fields[j]]
+
+			if x == y {
+				return fields[i] < fields[j]
+			}
+
+			return x > y
+		})
+
+		for _, field := range fields {
+			out <- Record{strconv.Itoa(freq[field]), field}
+		}
+
+		return nil
+	}
+}
+
+// JoinFields returns a filter that writes each record as a line with fields
+// separated by 'delimiter'.
+func JoinFields(delimiter string) func(<-chan Record, io.Writer) error {
+	return func(in <-chan Record, w io.Writer) error {
+		for record := range in {
+			fmt.Fprintln(w, strings.Join(record, delimiter))
+		}
+
+		return nil
+	}
+}
+
+// ToCSV returns a filter that writes each record as a line of CSV with fields
+// separated by 'delimiter'.
+func ToCSV(delimiter string) func(<-chan Record, io.Writer) error {
+	return func(in <-chan Record, w io.Writer) error {
+		if utf8.RuneCount([]byte(delimiter)) > 1 {
+			return fmt.Errorf("delimeter must be a single rune, got: %s", delimiter)
+		}
+
+		writer := csv.NewWriter(w)
+		writer.Comma, _ = utf8.DecodeRuneInString(delimiter)
+
+		for record := range in {
+			writer.Write(record)
+		}
+
+		writer.Flush()
+
+		return writer.Error()
+	}
+}
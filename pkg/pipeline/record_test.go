@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRecordFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "apple,red,3\nbanana,yellow,1\ncherry,red,2\n"
+
+	tests := []struct {
+		in     func(io.Reader, chan<- Record) error
+		filter func(<-chan Record, chan<- Record) error
+		out    func(<-chan Record, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Fields(","), nil, JoinFields("\t"), input, "apple\tred\t3\nbanana\tyellow\t1\ncherry\tred\t2\n"},
+		{CSV(","), nil, JoinFields("|"), "one,\"t,w,o\"\n", "one|t,w,o\n"},
+		{Fields(","), nil, ToCSV(","), "one,t\"w\"o\n", "one,\"t\"\"w\"\"o\"\n"},
+		{ExtractFields(regexp.MustCompile(`(\w+)=(\d+)?`)), nil, JoinFields("\t"), "a=1 b= c=3\nnone\n", "a\t1\nb\t\nc\t3\n"},
+		{Fields(","), nil, ToCSV("\t"), input, "apple\tred\t3\nbanana\tyellow\t1\ncherry\tred\t2\n"},
+		{Fields(","), MatchField(2, "red"), JoinFields(","), input, "apple,red,3\ncherry,red,2\n"},
+		{Fields(","), MatchField(9, "red"), JoinFields(","), input, ""},
+		{Fields(","), NotMatchField(2, "red"), JoinFields(","), input, "banana,yellow,1\n"},
+		{Fields(","), ReplaceField(2, "e", "E"), JoinFields(","), input, "apple,rEd,3\nbanana,yEllow,1\ncherry,rEd,2\n"},
+		{Fields(","), ReplaceField(9, "e", "E"), JoinFields(","), input, input},
+		{Fields(","), SelectFields("3,1"), JoinFields(","), input, "3,apple\n1,banana\n2,cherry\n"},
+		{Fields(","), SortField(3), JoinFields(","), input, "banana,yellow,1\ncherry,red,2\napple,red,3\n"},
+		{Fields(","), FrequencyField(2), JoinFields(" "), input, "2 red\n1 yellow\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			p := NewPipeline(strings.NewReader(tc.input))
+			p.ToRecords(tc.in)
+			if tc.filter != nil {
+				p.FilterRecords(tc.filter)
+			}
+			p.FromRecords(tc.out)
+
+			if _, err := p.Output(got); err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestRecordFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(<-chan Record, chan<- Record) error
+		want   string
+	}{
+		{SelectFields("1,0"), "list of columns must be comma separated list of positive ints, got: 1,0"},
+		{SelectFields("-1"), "list of columns must be comma separated list of positive ints, got: -1"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			p := NewPipeline(strings.NewReader("apple,red\n"))
+			p.ToRecords(Fields(","))
+			p.FilterRecords(tc.filter)
+			p.FromRecords(JoinFields(","))
+
+			_, err := p.Output(io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// A Seed seeds the random number generator of a filter, supplied by the
+// pipeline rather than given as an argument. If 'Set' is false the current time
+// is used so each run is different.
+type Seed struct {
+	Value int64
+	Set   bool
+}
+
+// newRand returns a random number generator seeded with 'seed'.
+func newRand(seed Seed) *rand.Rand {
+	s := seed.Value
+	if !seed.Set {
+		s = time.Now().UnixNano()
+	}
+
+	return rand.New(rand.NewSource(s))
+}
+
+// Sample returns a filter that writes 'n' lines chosen at random, in the order
+// they were read, using a random number generator seeded with 'seed'. Only 'n'
+// lines are held in memory, so the input can be of any size.
+func Sample(seed Seed, n int) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if n <= 0 {
+			return nil
+		}
+
+		type sample struct {
+			n    int
+			text string
+		}
+
+		rnd := newRand(seed)
+		reservoir := []sample{}
+
+		scanner := bufio.NewScanner(r)
+
+		i := 0
+		for scanner.Scan() {
+			if i < n {
+				reservoir = append(reservoir, sample{i, scanner.Text()})
+			} else if j := rnd.Intn(i + 1); j < n {
+				reservoir[j] = sample{i, scanner.Text()}
+			}
+			i++
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		sort.Slice(reservoir, func(i, j int) bool {
+			return reservoir[i].n < reservoir[j].n
+		})
+
+		for _, s := range reservoir {
+			fmt.Fprintln(w, s.text)
+		}
+
+		return nil
+	}
+}
+
+// SampleRate returns a filter that writes each line with the probability
+// 'rate', between 0 and 1, using a random number generator seeded with 'seed'.
+func SampleRate(seed Seed, rate float64) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("rate must be between 0 and 1, got: %v", rate)
+		}
+
+		rnd := newRand(seed)
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			if rnd.Float64() < rate {
+				fmt.Fprintln(w, scanner.Text())
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Shuffle returns a filter that writes all lines in a random order using a
+// random number generator seeded with 'seed'.
+func Shuffle(seed Seed) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		lines := []string{}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+
+		rnd := newRand(seed)
+		rnd.Shuffle(len(lines), func(i, j int) {
+			lines[i], lines[j] = lines[j], lines[i]
+		})
+
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestSampleFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	seed := Seed{1, true}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Sample(seed, 0), input, ""},
+		{Sample(seed, 3), input, "5\n7\n8\n"},
+		{Sample(seed, 10), input, input},
+		{Sample(seed, 20), input, input},
+		{SampleRate(seed, 0), input, ""},
+		{SampleRate(seed, 0.5), input, "4\n5\n7\n8\n9\n10\n"},
+		{SampleRate(seed, 1), input, input},
+		{Shuffle(seed), "", ""},
+		{Shuffle(seed), input, "2\n8\n5\n1\n10\n3\n4\n6\n9\n7\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestSampleFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{SampleRate(Seed{}, -0.5), "rate must be between 0 and 1, got: -0.5"},
+		{SampleRate(Seed{}, 1.5), "rate must be between 0 and 1, got: 1.5"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("a\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
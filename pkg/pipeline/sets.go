@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// readLines returns all lines read from 'r'.
+func readLines(r io.Reader) ([]string, error) {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// readSet returns the set of distinct lines read from 'r'.
+func readSet(r io.Reader) (map[string]bool, error) {
+	set := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		set[scanner.Text()] = true
+	}
+
+	return set, scanner.Err()
+}
+
+// Intersect returns a filter that writes the distinct lines that are also in
+// the 'file', in the order they were first read.
+func Intersect(file File) func(io.Reader, io.Writer) error {
+	return setLines(file, true)
+}
+
+// Except returns a filter that writes the distinct lines that aren't in the
+// 'file', in the order they were first read.
+func Except(file File) func(io.Reader, io.Writer) error {
+	return setLines(file, false)
+}
+
+func setLines(file File, in bool) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		other, err := readSet(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", file.Name, err)
+		}
+
+		seen := map[string]bool{}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			line := scanner.Text()
+			if seen[line] || other[line] != in {
+				continue
+			}
+
+			seen[line] = true
+			fmt.Fprintln(w, line)
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Union returns a filter that writes the distinct lines of the input followed
+// by the distinct lines of the 'file' that weren't in the input, in the order
+// they were first read.
+func Union(file File) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		seen := map[string]bool{}
+
+		write := func(r io.Reader) error {
+			scanner := bufio.NewScanner(r)
+
+			for scanner.Scan() {
+				line := scanner.Text()
+				if seen[line] {
+					continue
+				}
+
+				seen[line] = true
+				fmt.Fprintln(w, line)
+			}
+
+			return scanner.Err()
+		}
+
+		if err := write(r); err != nil {
+			return err
+		}
+
+		if err := write(file); err != nil {
+			return fmt.Errorf("error reading %s: %w", file.Name, err)
+		}
+
+		return nil
+	}
+}
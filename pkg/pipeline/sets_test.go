@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestSetFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "apple\nbanana\napple\ncherry\n"
+
+	other := func(s string) File {
+		return File{Name: "other.txt", Reader: strings.NewReader(s)}
+	}
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Intersect(other("")), input, ""},
+		{Intersect(other("cherry\napple\ndate\n")), input, "apple\ncherry\n"},
+		{Except(other("")), input, "apple\nbanana\ncherry\n"},
+		{Except(other("cherry\napple\ndate\n")), input, "banana\n"},
+		{Union(other("")), input, "apple\nbanana\ncherry\n"},
+		{Union(other("date\ncherry\ndate\n")), input, "apple\nbanana\ncherry\ndate\n"},
+		{Union(other("date\n")), "", "date\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dyson/pipesore/pkg/textwidth"
+)
+
+// mapLines returns a filter that writes each line transformed by 'fn'.
+func mapLines(fn func(line string) string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			fmt.Fprintln(w, fn(scanner.Text()))
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Trim returns a filter that writes all lines with leading and trailing white
+// space removed.
+func Trim() func(io.Reader, io.Writer) error {
+	return mapLines(strings.TrimSpace)
+}
+
+// TrimPrefix returns a filter that writes all lines with 'prefix' removed from
+// the start of the line.
+func TrimPrefix(prefix string) func(io.Reader, io.Writer) error {
+	return mapLines(func(line string) string {
+		return strings.TrimPrefix(line, prefix)
+	})
+}
+
+// TrimSuffix returns a filter that writes all lines with 'suffix' removed from
+// the end of the line.
+func TrimSuffix(suffix string) func(io.Reader, io.Writer) error {
+	return mapLines(func(line string) string {
+		return strings.TrimSuffix(line, suffix)
+	})
+}
+
+// PadLeft returns a filter that writes all lines left padded with spaces to the
+// display 'width'.
+func PadLeft(width int) func(io.Reader, io.Writer) error {
+	return mapLines(func(line string) string {
+		return padLeft(line, width)
+	})
+}
+
+// PadRight returns a filter that writes all lines right padded with spaces to
+// the display 'width'.
+func PadRight(width int) func(io.Reader, io.Writer) error {
+	return mapLines(func(line string) string {
+		return padRight(line, width)
+	})
+}
+
+// Wrap returns a filter that writes all lines wrapped to the display 'width'.
+// Lines are broken at spaces where possible and words wider than 'width' are
+// broken between runes.
+func Wrap(width int) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if width < 1 {
+			return fmt.Errorf("width must be greater than 0, got: %d", width)
+		}
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			for _, line := range textwidth.Wrap(scanner.Text(), width) {
+				fmt.Fprintln(w, line)
+			}
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Truncate returns a filter that writes all lines cut to the display 'width'
+// with 'tail' appended to lines that were cut. The 'tail' counts towards the
+// 'width'.
+func Truncate(width int, tail string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		if width < 0 {
+			return fmt.Errorf("width must be 0 or more, got: %d", width)
+		}
+
+		return mapLines(func(line string) string {
+			return textwidth.Truncate(line, width, tail)
+		})(r, w)
+	}
+}
+
+// Number returns a filter that writes all lines prefixed by their line number,
+// starting at 'start', right aligned to 'width' and followed by 'separator'.
+func Number(start, width int, separator string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		n := start
+
+		scanner := bufio.NewScanner(r)
+
+		for scanner.Scan() {
+			fmt.Fprintf(w, "%*d%s%s\n", width, n, separator, scanner.Text())
+			n++
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Prefix returns a filter that writes all lines prefixed by 'template' with
+// the placeholders "{n}" replaced by the line number and "{file}" by the name
+// of the file the line was read from. Files are only known to the first filter
+// in a pipeline, where line numbers restart at 1 for each file. Otherwise the
+// file is "-".
+func Prefix(template string) func(io.Reader, io.Writer) error {
+	return templateLines(template, "")
+}
+
+// Suffix returns a filter that writes all lines suffixed by 'template' with the
+// same placeholders as Prefix.
+func Suffix(template string) func(io.Reader, io.Writer) error {
+	return templateLines("", template)
+}
+
+func templateLines(prefix, suffix string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		return eachFile(r, func(f File) error {
+			n := 0
+
+			scanner := bufio.NewScanner(f)
+
+			for scanner.Scan() {
+				n++
+
+				replacer := strings.NewReplacer("{n}", strconv.Itoa(n), "{file}", f.Name)
+				fmt.Fprintln(w, replacer.Replace(prefix)+scanner.Text()+replacer.Replace(suffix))
+			}
+
+			return scanner.Err()
+		})
+	}
+}
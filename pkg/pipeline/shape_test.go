@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestShapeFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Trim(), " \ta b  \n", "a b\n"},
+		{TrimPrefix("> "), "> a\n>b\n", "a\n>b\n"},
+		{TrimSuffix(","), "a,\nb,,\n", "a\nb,\n"},
+		{PadLeft(4), "ab\n日本\nabcde\n", "  ab\n日本\nabcde\n"},
+		{PadRight(3), "ab\n🍎\n", "ab \n🍎 \n"},
+		{Wrap(5), "aaa bb c dddddddd\n\n", "aaa\nbb c\nddddd\nddd\n\n"},
+		{Wrap(4), "日本語 テキスト\n", "日本\n語\nテキ\nスト\n"},
+		{Wrap(4), "🍎🍎🍎 a\n", "🍎🍎\n🍎 a\n"},
+		{Truncate(5, "…"), "abcdef\nabcde\n", "abcd…\nabcde\n"},
+		{Truncate(5, "..."), "日本語テキスト\n", "日...\n"},
+		{Truncate(2, "..."), "abcdef\n", "..\n"},
+		{Truncate(3, ""), "éééé\n", "ééé\n"},
+		{Number(1, 3, ": "), "a\nb\n", "  1: a\n  2: b\n"},
+		{Number(9, 0, "\t"), "a\n\nc\n", "9\ta\n10\t\n11\tc\n"},
+		{Number(-1, 1, " "), "a\nb\n", "-1 a\n0 b\n"},
+		{Prefix("{n}: "), "a\nb\n", "1: a\n2: b\n"},
+		{Suffix(" ({n})"), "a\nb\n", "a (1)\nb (2)\n"},
+		{Suffix(" {file}:{n}"), "a\n", "a -:1\n"},
+		{Suffix("{x}"), "a\n", "a{x}\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestShapeFilterErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		want   string
+	}{
+		{Wrap(0), "width must be greater than 0, got: 0"},
+		{Truncate(-1, "…"), "width must be 0 or more, got: -1"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader("a\n"), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"bufio"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pathSafe returns 's' with path separators replaced so that a column value
+// can't change the directory of a file.
+func pathSafe(s string) string {
+	if s == "." || s == ".." {
+		return "_"
+	}
+
+	return strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(s)
+}
+
+// splitToOpenFiles is the most files SplitTo keeps open at once. To open
+// another the least recently written file is closed, and it's reopened to
+// append to if it's written to again.
+const splitToOpenFiles = 64
+
+// SplitTo returns a filter that writes each line to the file named by
+// 'template' with the placeholders of Format replaced by the columns of the
+// line defined by splitting with the 'delimiter', for example "out/{1}.log".
+// Path separators in columns are replaced by "_". Files (and their
+// directories) are created, or truncated, when they're first written to by
+// 'outputs' and nothing is written to the output. A line that gives an empty
+// file name is an error.
+func SplitTo(outputs Outputs, template, delimiter string) func(io.Reader, io.Writer) error {
+	return splitTo(outputs, template, delimiter, splitToOpenFiles)
+}
+
+func splitTo(outputs Outputs, template, delimiter string, maxOpen int) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) (err error) {
+		type file struct {
+			name string
+			f    io.WriteCloser
+			*bufio.Writer
+		}
+
+		create := outputs.create()
+
+		// the open files, most recently written first
+		open := list.New()
+		elements := map[string]*list.Element{}
+
+		closeFile := func(e *list.Element) error {
+			f := open.Remove(e).(*file)
+			delete(elements, f.name)
+
+			return errors.Join(f.Flush(), f.f.Close())
+		}
+
+		defer func() {
+			for open.Len() > 0 {
+				err = errors.Join(err, closeFile(open.Front()))
+			}
+		}()
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			name := formatLine(template, scanner.Text(), delimiter, pathSafe)
+			if name == "" || strings.HasSuffix(name, "/") || strings.HasSuffix(name, string(filepath.Separator)) {
+				return fmt.Errorf("line %d: file name must not be empty, got: %q from template: %q", n, name, template)
+			}
+
+			e, ok := elements[name]
+			if ok {
+				open.MoveToFront(e)
+			} else {
+				if open.Len() >= maxOpen {
+					if err := closeFile(open.Back()); err != nil {
+						return err
+					}
+				}
+
+				if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+					return err
+				}
+
+				f, err := create(name)
+				if err != nil {
+					return err
+				}
+
+				e = open.PushFront(&file{name, f, bufio.NewWriter(f)})
+				elements[name] = e
+			}
+
+			if _, err := e.Value.(*file).WriteString(scanner.Text() + "\n"); err != nil {
+				return err
+			}
+		}
+
+		return scanner.Err()
+	}
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitTo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	input := "error,disk full\ninfo,started\nerror,timeout\n../x,escaped\n"
+
+	got := &bytes.Buffer{}
+
+	err := SplitTo(Outputs{}, filepath.Join(dir, "out", "{1}.log"), ",")(strings.NewReader(input), got)
+	if err != nil {
+		t.Fatalf("error executing filter: %v", err)
+	}
+
+	if got.Len() != 0 {
+		log.Fatalf("wanted no output, got: %q", got.String())
+	}
+
+	want := map[string]string{
+		"error.log": "error,disk full\nerror,timeout\n",
+		"info.log":  "info,started\n",
+		".._x.log":  "../x,escaped\n",
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(want) != len(entries) {
+		log.Fatalf("wanted %d files, got: %v", len(want), entries)
+	}
+
+	for name, want := range want {
+		got, err := os.ReadFile(filepath.Join(dir, "out", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want != string(got) {
+			log.Fatalf("(file %s) wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+}
+
+func TestSplitToOpenFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// existing files are truncated when first written to, but not when
+	// reopened after being closed to open another file
+	if err := os.WriteFile(filepath.Join(dir, "a.log"), []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "a,1\nb,2\nc,3\na,4\nb,5\nc,6\na,7\n"
+
+	err := splitTo(Outputs{}, filepath.Join(dir, "{1}.log"), ",", 2)(strings.NewReader(input), &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("error executing filter: %v", err)
+	}
+
+	want := map[string]string{
+		"a.log": "a,1\na,4\na,7\n",
+		"b.log": "b,2\nb,5\n",
+		"c.log": "c,3\nc,6\n",
+	}
+
+	for name, want := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want != string(got) {
+			log.Fatalf("(file %s) wanted: %q, got: %q", name, want, string(got))
+		}
+	}
+}
+
+func TestSplitToErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{SplitTo(Outputs{}, "{2}", ","), "a\n", `line 1: file name must not be empty, got: "" from template: "{2}"`},
+		{SplitTo(Outputs{}, dir+"/{2}/", ","), "a,1\n", fmt.Sprintf("line 1: file name must not be empty, got: %q from template: %q", dir+"/1/", dir+"/{2}/")},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.filter(strings.NewReader(tc.input), io.Discard)
+			if err == nil || tc.want != err.Error() {
+				t.Fatalf("(test %d) wanted error: %q, got: %v", k, tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// placeholder matches the 1-indexed column placeholders of Format.
+var placeholder = regexp.MustCompile(`\{([0-9]+)\}`)
+
+// Format returns a filter that writes all lines rebuilt from 'format' with
+// the placeholders "{1}", "{2}" and so on replaced by the 1-indexed column of
+// the line defined by splitting with the 'delimiter'. The placeholder "{0}" is
+// the whole line and columns the line doesn't have are empty.
+func Format(format, delimiter string) func(io.Reader, io.Writer) error {
+	return mapLines(func(line string) string {
+		return formatLine(format, line, delimiter, func(s string) string {
+			return s
+		})
+	})
+}
+
+// formatLine returns 'format' with the placeholders of Format replaced by the
+// columns of 'line' after they are passed through 'fn'.
+func formatLine(format, line, delimiter string, fn func(string) string) string {
+	lineColumns := Record(strings.Split(line, delimiter))
+
+	return placeholder.ReplaceAllStringFunc(format, func(p string) string {
+		column, err := strconv.Atoi(p[1 : len(p)-1])
+		if err != nil {
+			return ""
+		}
+
+		if column == 0 {
+			return fn(line)
+		}
+
+		return fn(lineColumns.field(column))
+	})
+}
+
+// templateFuncs are the functions available to templates.
+var templateFuncs = template.FuncMap{
+	"upper":    func(s string) string { return cases.Upper(language.Und).String(s) },
+	"lower":    func(s string) string { return cases.Lower(language.Und).String(s) },
+	"title":    func(s string) string { return cases.Title(language.Und).String(s) },
+	"trim":     strings.TrimSpace,
+	"replace":  func(old, replace, s string) string { return strings.ReplaceAll(s, old, replace) },
+	"padLeft":  func(width int, s string) string { return padLeft(s, width) },
+	"padRight": func(width int, s string) string { return padRight(s, width) },
+}
+
+// ParseTemplate returns the text/template 'text' with the functions upper,
+// lower, title, trim, replace, padLeft and padRight available.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("template").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// Template returns a filter that writes all lines rebuilt by executing the
+// text/template 'tmpl' (see ParseTemplate) with the line as ".Line", the line
+// number as ".N" and the 1-indexed columns of the line defined by splitting
+// with the 'delimiter' as ".F1", ".F2" and so on. Columns the line doesn't
+// have are empty.
+func Template(tmpl *template.Template, delimiter string) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			data := map[string]string{
+				"Line": scanner.Text(),
+				"N":    strconv.Itoa(n),
+			}
+
+			for i, column := range strings.Split(scanner.Text(), delimiter) {
+				data["F"+strconv.Itoa(i+1)] = column
+			}
+
+			if err := tmpl.Execute(w, data); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+
+			fmt.Fprintln(w)
+		}
+
+		return scanner.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func mustParseTemplate(text string) *template.Template {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+
+	return tmpl
+}
+
+func TestTemplateFilters(t *testing.T) {
+	t.Parallel()
+
+	input := "alice,admin\nbob\n"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Format("{2} -> {1}", ","), input, "admin -> alice\n -> bob\n"},
+		{Format("{0} ({1}) {x} {{1}}", ","), input, "alice,admin (alice) {x} {alice}\nbob (bob) {x} {bob}\n"},
+		{Format("{99999999999999999999}", ","), input, "\n\n"},
+		{Template(mustParseTemplate("{{.F2}} {{upper .F1}}"), ","), input, "admin ALICE\n BOB\n"},
+		{Template(mustParseTemplate("{{.N}}:{{.Line}}:{{.F9}}"), ","), input, "1:alice,admin:\n2:bob:\n"},
+		{Template(mustParseTemplate("{{padRight 6 .F1}}|{{padLeft 6 .F2 | title}}|"), ","), input, "alice | Admin|\nbob   |      |\n"},
+		{Template(mustParseTemplate(`{{lower .Line | trim | replace "a" "4"}}`), ","), " ALICE \n", "4lice\n"},
+		{Template(mustParseTemplate(`{{if eq .F2 "admin"}}{{.F1}}{{end}}`), ","), input, "alice\n\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ParseNormForm returns the Unicode normalization form named 'form': "NFC",
+// "NFD", "NFKC" or "NFKD".
+func ParseNormForm(form string) (norm.Form, error) {
+	switch form {
+	case "NFC":
+		return norm.NFC, nil
+	case "NFD":
+		return norm.NFD, nil
+	case "NFKC":
+		return norm.NFKC, nil
+	case "NFKD":
+		return norm.NFKD, nil
+	}
+
+	return 0, fmt.Errorf("normalization form must be one of \"NFC\", \"NFD\", \"NFKC\" or \"NFKD\", got: %q", form)
+}
+
+// transformLines returns a filter that writes each line transformed by the
+// transformer returned by 'newTransformer'. Transformers aren't safe for
+// concurrent use so a new one is created each time the filter runs.
+func transformLines(newTransformer func() transform.Transformer) func(io.Reader, io.Writer) error {
+	return func(r io.Reader, w io.Writer) error {
+		t := newTransformer()
+
+		scanner := bufio.NewScanner(r)
+
+		n := 0
+		for scanner.Scan() {
+			n++
+
+			s, _, err := transform.String(t, scanner.Text())
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+
+			fmt.Fprintln(w, s)
+		}
+
+		return scanner.Err()
+	}
+}
+
+// Upper returns a filter that writes all lines in upper case using Unicode
+// case mapping, for example "straße" becomes "STRASSE".
+func Upper() func(io.Reader, io.Writer) error {
+	return transformLines(func() transform.Transformer {
+		return cases.Upper(language.Und)
+	})
+}
+
+// Lower returns a filter that writes all lines in lower case using Unicode
+// case mapping.
+func Lower() func(io.Reader, io.Writer) error {
+	return transformLines(func() transform.Transformer {
+		return cases.Lower(language.Und)
+	})
+}
+
+// Title returns a filter that writes all lines with the first letter of each
+// word in title case and the rest in lower case.
+func Title() func(io.Reader, io.Writer) error {
+	return transformLines(func() transform.Transformer {
+		return cases.Title(language.Und)
+	})
+}
+
+// Normalize returns a filter that writes all lines in the Unicode
+// normalization 'form' so visually identical lines are equal.
+func Normalize(form norm.Form) func(io.Reader, io.Writer) error {
+	return transformLines(func() transform.Transformer {
+		return form
+	})
+}
+
+// StripAccents returns a filter that writes all lines with accents and other
+// combining marks removed, for example "café" becomes "cafe".
+func StripAccents() func(io.Reader, io.Writer) error {
+	return transformLines(func() transform.Transformer {
+		return transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	})
+}
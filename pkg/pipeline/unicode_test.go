@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestUnicodeFilters(t *testing.T) {
+	t.Parallel()
+
+	// "é" composed and decomposed
+	composed, decomposed := "caf\u00e9", "cafe\u0301"
+
+	tests := []struct {
+		filter func(io.Reader, io.Writer) error
+		input  string
+		want   string
+	}{
+		{Upper(), "straße ǆ\n", "STRASSE Ǆ\n"},
+		{Lower(), "ΟΔΟΣ ÉCOLE\n", "οδος école\n"},
+		{Title(), "hello wORLD élan\n", "Hello World Élan\n"},
+		{Normalize(norm.NFC), decomposed + "\n", composed + "\n"},
+		{Normalize(norm.NFD), composed + "\n", decomposed + "\n"},
+		{Normalize(norm.NFKC), "ﬁ ①\n", "fi 1\n"},
+		{Normalize(norm.NFKD), "ﬁ\n", "fi\n"},
+		{StripAccents(), composed + " " + decomposed + " Ångström\n", "cafe cafe Angstrom\n"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+
+			err := tc.filter(strings.NewReader(tc.input), got)
+			if err != nil {
+				t.Fatalf("(test: %d) error executing filter: %v: input: %v", k, err, tc.input)
+			}
+
+			if tc.want != got.String() {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got.String())
+			}
+		})
+	}
+}
+
+func TestTransformLinesErrors(t *testing.T) {
+	t.Parallel()
+
+	// Latin-1 can't encode "世"
+	filter := transformLines(func() transform.Transformer {
+		return charmap.ISO8859_1.NewEncoder()
+	})
+
+	want := "line 2: encoding: rune not supported by encoding."
+
+	err := filter(strings.NewReader("a\n世\n"), &bytes.Buffer{})
+	if err == nil || err.Error() != want {
+		t.Fatalf("wanted error: %q, got: %v", want, err)
+	}
+}
@@ -0,0 +1,102 @@
+package textwidth
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// String returns the number of columns needed to display s in a terminal.
+func String(s string) int {
+	w := 0
+	for _, r := range s {
+		w += Rune(r)
+	}
+
+	return w
+}
+
+// Rune returns the number of columns needed to display r in a terminal. Wide
+// and fullwidth runes (eg CJK and most emoji) take two columns, combining
+// marks, format and control characters take none and all other runes take
+// one.
+func Rune(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc) {
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+
+	return 1
+}
+
+// Wrap returns s split into lines no wider than width columns. Lines are
+// broken at spaces where possible and words wider than width are broken
+// between runes. If width is less than 1 s is returned unchanged.
+func Wrap(s string, width int) []string {
+	if width < 1 {
+		return []string{s}
+	}
+
+	lines := []string{}
+	line, lineWidth := "", 0
+
+	for i, word := range strings.Split(s, " ") {
+		wordWidth := String(word)
+
+		if i > 0 && lineWidth+1+wordWidth <= width {
+			line += " " + word
+			lineWidth += 1 + wordWidth
+			continue
+		}
+
+		if i > 0 {
+			lines = append(lines, line)
+		}
+
+		line, lineWidth = "", 0
+
+		for _, r := range word {
+			if lineWidth+Rune(r) > width && line != "" {
+				lines = append(lines, line)
+				line, lineWidth = "", 0
+			}
+
+			line += string(r)
+			lineWidth += Rune(r)
+		}
+	}
+
+	return append(lines, line)
+}
+
+// Truncate returns s cut to no wider than width columns with tail appended if
+// s is wider than width. The tail counts towards the width. A negative width is
+// treated as 0.
+func Truncate(s string, width int, tail string) string {
+	width = max(width, 0)
+
+	if String(s) <= width {
+		return s
+	}
+
+	width -= String(tail)
+	if width < 0 {
+		return Truncate(tail, width+String(tail), "")
+	}
+
+	w := 0
+	for i, r := range s {
+		if w+Rune(r) > width {
+			return s[:i] + tail
+		}
+
+		w += Rune(r)
+	}
+
+	return s + tail
+}
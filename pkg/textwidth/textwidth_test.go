@@ -0,0 +1,151 @@
+package textwidth
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+)
+
+func TestRune(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input rune
+		want  int
+	}{
+		{'a', 1},
+		{'é', 1},
+		{'ｱ', 1},
+		{'世', 2},
+		{'Ａ', 2},
+		{'😀', 2},
+		{'\u0301', 0},
+		{'\u20dd', 0},
+		{'\u200b', 0},
+		{'\u200d', 0},
+		{'\t', 0},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := Rune(tc.input)
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %d, got: %d", k, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"he\u0301llo", 5},
+		{"日本語", 6},
+		{"a日b", 4},
+		{"a\u200bb", 2},
+		{"👍\u200d", 2},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := String(tc.input)
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %d, got: %d", k, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		width int
+		want  []string
+	}{
+		{"abc", 0, []string{"abc"}},
+		{"ab cd ef", 5, []string{"ab cd", "ef"}},
+		{"abcdef", 4, []string{"abcd", "ef"}},
+		{"日本語 テキスト", 6, []string{"日本語", "テキス", "ト"}},
+		{"日本", 3, []string{"日", "本"}},
+		{"e\u0301e\u0301 ab", 4, []string{"e\u0301e\u0301", "ab"}},
+		{"a\u200bb c", 4, []string{"a\u200bb c"}},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := Wrap(tc.input, tc.width)
+
+			if !reflect.DeepEqual(tc.want, got) {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		width int
+		tail  string
+		want  string
+	}{
+		{"abc", 3, "...", "abc"},
+		{"abcdef", 5, "...", "ab..."},
+		{"abc", 0, "...", ""},
+		{"x", -1, "", ""},
+		{"abc", -5, "...", ""},
+		{"", -1, "…", ""},
+		{"日本語", 6, "…", "日本語"},
+		{"日本語", 5, "…", "日本…"},
+		{"日本", 1, "…", "…"},
+		{"ab\u0301c", 2, "", "ab\u0301"},
+		{"a\u200bbc", 2, "", "a\u200bb"},
+	}
+
+	for k, tc := range tests {
+		// TODO: remove shadowing once using go v1.22
+		k := k
+		tc := tc
+
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			t.Parallel()
+
+			got := Truncate(tc.input, tc.width, tc.tail)
+
+			if tc.want != got {
+				log.Fatalf("(test %d) wanted: %q, got: %q", k, tc.want, got)
+			}
+		})
+	}
+}